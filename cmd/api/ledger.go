@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/blackcloro/transaction-processor/internal/config"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/infrastructure/database"
+)
+
+const ledgerUsage = `Usage: transaction-processor ledger <command> <account-id>
+
+Commands:
+  verify    compare the stored balance of the account with its ledger
+  rebuild   overwrite the stored balance of the account with its ledger balance
+`
+
+// runLedger implements the `ledger` subcommand. It prints the result as JSON
+// and, for verify, returns a non-zero exit code when the stored balance
+// differs from the ledger.
+func runLedger(cfg *config.Config, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprint(os.Stderr, ledgerUsage)
+		return exitUsage
+	}
+	accountID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || accountID <= 0 {
+		fmt.Fprint(os.Stderr, ledgerUsage)
+		return exitUsage
+	}
+
+	db, err := database.NewPostgresDB(cfg.DB.DSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return exitFailure
+	}
+	defer db.Close()
+
+	service := ledger.NewService(database.NewPostgresLedgerRepository(db), database.NewPostgresAccountRepository(db))
+	ctx := context.Background()
+
+	var result any
+	balanced := true
+	switch args[0] {
+	case "verify":
+		verification, err := service.Verify(ctx, accountID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ledger verification failed: %v\n", err)
+			return exitFailure
+		}
+		result, balanced = verification, verification.Balanced()
+	case "rebuild":
+		acc, err := service.Rebuild(ctx, accountID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ledger rebuild failed: %v\n", err)
+			return exitFailure
+		}
+		result = acc
+	default:
+		fmt.Fprint(os.Stderr, ledgerUsage)
+		return exitUsage
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write result: %v\n", err)
+		return exitFailure
+	}
+
+	if !balanced {
+		return 3
+	}
+	return exitOK
+}
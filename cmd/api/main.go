@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,59 +13,316 @@ import (
 	"github.com/blackcloro/transaction-processor/internal/api/handlers"
 	"github.com/blackcloro/transaction-processor/internal/config"
 	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/processing"
+	"github.com/blackcloro/transaction-processor/internal/domain/reconciliation"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
 	"github.com/blackcloro/transaction-processor/internal/infrastructure/database"
+	"github.com/blackcloro/transaction-processor/internal/lifecycle"
+	"github.com/blackcloro/transaction-processor/internal/metrics"
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
+	"github.com/blackcloro/transaction-processor/internal/signing"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
 	"github.com/blackcloro/transaction-processor/internal/worker"
+	"github.com/blackcloro/transaction-processor/migrations"
 	"github.com/blackcloro/transaction-processor/pkg/logger"
 )
 
+const (
+	// postProcessingLockKey is the advisory lock replicas compete for before
+	// post-processing.
+	postProcessingLockKey int64 = 0x7470_0001
+	lockHeartbeat               = 5 * time.Second
+	shutdownTimeout             = 30 * time.Second
+)
+
+// Exit codes of the server.
+const (
+	exitOK              = 0
+	exitFailure         = 1
+	exitUsage           = 2
+	exitShutdownTimeout = 3
+)
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitFailure)
+	}
+
+	if err := logger.InitLogger(logger.Options{Format: cfg.Log.Format, Level: cfg.Log.Level}); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			os.Exit(runReconcile(cfg, os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(cfg, os.Args[2:]))
+		case "ledger":
+			os.Exit(runLedger(cfg, os.Args[2:]))
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command %q\n", os.Args[1])
+			os.Exit(exitUsage)
+		}
 	}
 
-	logger.InitLogger()
+	os.Exit(runServer(cfg))
+}
 
-	db, err := database.NewPostgresDB(cfg.DB.DSN)
+// runServer serves the API and runs the workers until SIGINT or SIGTERM, then
+// drains them and returns the process exit code.
+func runServer(cfg *config.Config) int {
+	postProcessConfig, err := postProcessConfigFrom(cfg.Worker)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return exitFailure
+	}
+	rateLimitPolicies, err := rateLimitPoliciesFrom(cfg.RateLimit)
 	if err != nil {
-		logger.Warn("Failed to connect to database", err)
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return exitFailure
 	}
-	defer db.Close()
 
-	accountRepo := database.NewPostgresAccountRepository(db)
-	transactionRepo := database.NewPostgresTransactionRepository(db)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.ConnectPostgresDB(ctx, cfg.DB.DSN, cfg.DB.ConnectAttempts, cfg.DB.ConnectBackoff)
+	if err != nil {
+		logger.Error("Failed to connect to database", err)
+		return exitFailure
+	}
+
+	if cfg.DB.AutoMigrate {
+		if err := migrateUp(cfg.DB.DSN); err != nil {
+			logger.Error("Failed to migrate database", err)
+			db.Close()
+			return exitFailure
+		}
+	}
+	schemaVersion, err := migrations.Latest()
+	if err != nil {
+		logger.Error("Failed to read embedded migrations", err)
+		db.Close()
+		return exitFailure
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error("Failed to set up tracing", err)
+		db.Close()
+		return exitFailure
+	}
+
+	// Repositories trace every statement; the pool itself serves the
+	// health checks and the leader lock untraced
+	querier := database.NewTracingQuerier(db)
+
+	accountRepo := database.NewPostgresAccountRepository(querier)
+	transactionRepo := database.NewPostgresTransactionRepository(querier)
 
 	accountService := account.NewService(accountRepo)
-	transactionService := transaction.NewService(transactionRepo)
+	transactionService := transaction.NewService(transactionRepo, postProcessConfig)
+	processingService := processing.NewService(database.NewPostgresUnitOfWork(querier))
+
+	transactionHandler := handlers.NewTransactionHandler(processingService, transactionService)
+
+	accountHandler := handlers.NewAccountHandler(accountService)
+
+	apiKeyService := apikey.NewService(database.NewPostgresAPIKeyRepository(querier))
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	roundService := round.NewService(database.NewPostgresRoundRepository(querier))
+	roundHandler := handlers.NewRoundHandler(roundService, transactionService)
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == "postgres" {
+			store = database.NewPostgresRateLimitStore(querier)
+		}
+		limiter = ratelimit.NewLimiter(store, rateLimitPolicies)
+	}
+
+	var verifier *signing.Verifier
+	if cfg.Signing.Enabled {
+		var store signing.Store = database.NewPostgresSignatureStore(querier)
+		if cfg.Signing.Store == "memory" {
+			store = signing.NewMemoryStore()
+		}
+		verifier = signing.NewVerifier(cfg.Signing.Secrets, cfg.Signing.Tolerance, store)
+	}
 
-	transactionHandler := handlers.NewTransactionHandler(accountService, transactionService, db)
+	metrics.RegisterPool(db)
+	metrics.RegisterBalances(accountService)
 
-	server := api.NewServer(cfg, transactionHandler)
+	var locker worker.Locker
+	if cfg.Worker.LeaderElection {
+		locker = database.NewPostgresAdvisoryLock(db, postProcessingLockKey, instanceID(cfg.Worker), lockHeartbeat)
+	}
+	postProcessingWorker := worker.NewWorker(transactionService, cfg.Worker.Interval, locker)
+
+	// The workers run on a context of their own: on SIGTERM they are stopped
+	// through the manager, so an in-flight run can finish first
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
 
-	DBworker := worker.NewWorker(transactionService, cfg.Worker.Interval)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go DBworker.Start(ctx)
+	manager := lifecycle.NewManager(shutdownTimeout)
+	manager.Add(lifecycle.Component{
+		Name: "post-processing worker",
+		Run: func() error {
+			postProcessingWorker.Start(workerCtx)
+			return nil
+		},
+		Stop: postProcessingWorker.Stop,
+	})
+	workerStatuses := map[string]handlers.WorkerStatus{"post_processing": postProcessingWorker}
 
-	go func() {
-		if err := server.Start(); err != nil {
-			logger.Fatal("Failed to start server", err)
+	if cfg.Reconciliation.Interval > 0 {
+		reconciliationService := reconciliation.NewService(database.NewPostgresReconciliationRepository(querier))
+		reconciliationWorker := worker.NewReconciliationWorker(reconciliationService, cfg.Reconciliation.Interval, cfg.Reconciliation.Repair)
+		manager.Add(lifecycle.Component{
+			Name: "reconciliation worker",
+			Run: func() error {
+				reconciliationWorker.Start(workerCtx)
+				return nil
+			},
+			Stop: reconciliationWorker.Stop,
+		})
+		workerStatuses["reconciliation"] = reconciliationWorker
+	}
+
+	if cfg.Rounds.Timeout > 0 {
+		roundWorker := worker.NewRoundWorker(roundService, cfg.Rounds.Interval, cfg.Rounds.Timeout)
+		manager.Add(lifecycle.Component{
+			Name: "round worker",
+			Run: func() error {
+				roundWorker.Start(workerCtx)
+				return nil
+			},
+			Stop: roundWorker.Stop,
+		})
+		workerStatuses["rounds"] = roundWorker
+	}
+
+	healthHandler := handlers.NewHealthHandler(db, database.NewPostgresSchema(db), schemaVersion, workerStatuses)
+	server := api.NewServer(cfg, transactionHandler, accountHandler, healthHandler, apiKeyHandler, roundHandler, apiKeyService, limiter, verifier)
+	manager.Add(lifecycle.Component{
+		Name: "http server",
+		Run:  server.Start,
+		Stop: server.Shutdown,
+	})
+
+	// The pool goes last, once nothing can use it anymore
+	manager.OnClose(db.Close)
+	// Flush the spans of the drained requests and runs before exiting
+	manager.OnClose(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", err)
 		}
-	}()
+	})
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logger.Info("Shutting down server...")
+	err = manager.Run(ctx)
+	switch {
+	case err == nil:
+		logger.Info("Server exiting")
+		return exitOK
+	case errors.Is(err, lifecycle.ErrShutdownTimeout):
+		logger.Error("Shutdown did not complete in time", err)
+		return exitShutdownTimeout
+	default:
+		logger.Error("Server failed", err)
+		return exitFailure
+	}
+}
 
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// postProcessConfigFrom translates the worker configuration into the
+// post-processing settings of the transaction service.
+func postProcessConfigFrom(cfg config.WorkerConfig) (transaction.PostProcessConfig, error) {
+	policy, err := transaction.ParsePostProcessingPolicy(cfg.Policy)
+	if err != nil {
+		return transaction.PostProcessConfig{}, err
+	}
+	overdraftPolicy, err := transaction.ParseOverdraftPolicy(cfg.OverdraftPolicy)
+	if err != nil {
+		return transaction.PostProcessConfig{}, err
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", err)
+	selection := transaction.Selection{BatchSize: cfg.BatchSize, MinAge: cfg.MinAge}
+	if cfg.SourceType != "" {
+		sourceType := transaction.SourceType(cfg.SourceType)
+		switch sourceType {
+		case transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment:
+		default:
+			return transaction.PostProcessConfig{}, fmt.Errorf("unknown source type %q", cfg.SourceType)
+		}
+		selection.SourceType = &sourceType
 	}
+	if cfg.MinAmount != "" {
+		minAmount, err := money.Parse(cfg.MinAmount)
+		if err != nil {
+			return transaction.PostProcessConfig{}, fmt.Errorf("invalid minimum amount %q: %w", cfg.MinAmount, err)
+		}
+		selection.MinAmount = &minAmount
+	}
+
+	return transaction.PostProcessConfig{
+		Policy:          policy,
+		Selection:       selection,
+		OverdraftPolicy: overdraftPolicy,
+		DryRun:          cfg.DryRun,
+	}, nil
+}
 
-	logger.Info("Server exiting")
+// rateLimitPoliciesFrom reads the rate limits of the configuration.
+func rateLimitPoliciesFrom(cfg config.RateLimitConfig) (ratelimit.Policies, error) {
+	switch cfg.Store {
+	case "memory", "postgres":
+	default:
+		return ratelimit.Policies{}, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+
+	clientPolicy, err := ratelimit.ParsePolicy(cfg.Client)
+	if err != nil {
+		return ratelimit.Policies{}, err
+	}
+	defaultPolicy, err := ratelimit.ParsePolicy(cfg.Default)
+	if err != nil {
+		return ratelimit.Policies{}, err
+	}
+	sourceTypes, err := ratelimit.ParsePolicies(cfg.SourceTypes)
+	if err != nil {
+		return ratelimit.Policies{}, err
+	}
+	for name := range sourceTypes {
+		switch transaction.SourceType(name) {
+		case transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment:
+		default:
+			return ratelimit.Policies{}, fmt.Errorf("rate limit for unknown source type %q", name)
+		}
+	}
+	apiKeys, err := ratelimit.ParsePolicies(cfg.APIKeys)
+	if err != nil {
+		return ratelimit.Policies{}, err
+	}
+
+	return ratelimit.Policies{Client: clientPolicy, Default: defaultPolicy, SourceTypes: sourceTypes, APIKeys: apiKeys}, nil
+}
+
+// instanceID names this replica in the leader election.
+func instanceID(cfg config.WorkerConfig) string {
+	if cfg.InstanceID != "" {
+		return cfg.InstanceID
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return fmt.Sprintf("pid-%d", os.Getpid())
 }
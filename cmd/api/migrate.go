@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+
+	"github.com/blackcloro/transaction-processor/internal/config"
+	"github.com/blackcloro/transaction-processor/migrations"
+)
+
+const migrateUsage = `Usage: transaction-processor migrate <command>
+
+Commands:
+  up [N]      apply all or the next N pending migrations
+  down N      roll back the last N migrations
+  down -all   roll back every migration
+  version     print the current migration version
+  force V     set the version to V without running migrations, clearing the dirty flag
+`
+
+// runMigrate implements the `migrate` subcommand, which applies the
+// migrations embedded in the binary.
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return exitUsage
+	}
+
+	m, err := migrations.New(cfg.DB.DSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitFailure
+	}
+	defer m.Close()
+
+	switch command, rest := args[0], args[1:]; command {
+	case "up":
+		n, ok := parseSteps(rest, false)
+		if !ok {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return exitUsage
+		}
+		if n > 0 {
+			err = m.Steps(n)
+		} else {
+			err = m.Up()
+		}
+	case "down":
+		if len(rest) == 1 && rest[0] == "-all" {
+			err = m.Down()
+			break
+		}
+		n, ok := parseSteps(rest, true)
+		if !ok {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return exitUsage
+		}
+		err = m.Steps(-n)
+	case "version":
+		version, dirty, err := m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Println("no migrations applied")
+			return exitOK
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read version: %v\n", err)
+			return exitFailure
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+		return exitOK
+	case "force":
+		if len(rest) != 1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return exitUsage
+		}
+		version, convErr := strconv.Atoi(rest[0])
+		if convErr != nil || version < -1 {
+			fmt.Fprint(os.Stderr, migrateUsage)
+			return exitUsage
+		}
+		err = m.Force(version)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return exitUsage
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// parseSteps reads the optional step count of up and down.
+func parseSteps(args []string, required bool) (int, bool) {
+	if len(args) == 0 {
+		return 0, !required
+	}
+	if len(args) > 1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// migrateUp applies every pending migration, as the server does on start
+// when auto-migration is enabled.
+func migrateUp(dsn string) error {
+	m, err := migrations.New(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/blackcloro/transaction-processor/internal/config"
+	"github.com/blackcloro/transaction-processor/internal/domain/reconciliation"
+	"github.com/blackcloro/transaction-processor/internal/infrastructure/database"
+)
+
+// runReconcile implements the `reconcile` subcommand. It checks the balances
+// once, prints the report as JSON and returns a non-zero exit code when
+// discrepancies are left unrepaired.
+func runReconcile(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	accountID := fs.Int64("account", 0, "reconcile only this account (default: every account)")
+	repair := fs.Bool("repair", false, "repair inconsistent balances and record an audit for each repair")
+	note := fs.String("note", "manual reconciliation", "note stored on the audit records")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	db, err := database.NewPostgresDB(cfg.DB.DSN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	service := reconciliation.NewService(database.NewPostgresReconciliationRepository(db))
+	report, err := service.Run(context.Background(), reconciliation.Options{
+		AccountID: *accountID,
+		Repair:    *repair,
+		Note:      *note,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reconciliation failed: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	if !report.Clean() {
+		return 3
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/utils/v2"
+
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// accessLog assigns every request an ID, taken from the X-Request-ID header
+// when the caller sends one, stores it in the user context for the handlers'
+// logs and writes one structured access log record per request.
+func accessLog() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+
+		requestID := c.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = utils.UUIDv4()
+		}
+		c.Set(requestIDHeader, requestID)
+		c.SetUserContext(logger.WithRequestID(c.UserContext(), requestID))
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		args := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"route", c.Route().Path,
+			"status", status,
+			"latency", time.Since(start).String(),
+			"ip", c.IP(),
+		}
+		ctx := c.UserContext()
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logger.ErrorContext(ctx, "Request failed", err, args...)
+		case status >= fiber.StatusBadRequest:
+			logger.WarnContext(ctx, "Request rejected", args...)
+		default:
+			logger.InfoContext(ctx, "Request handled", args...)
+		}
+
+		return err
+	}
+}
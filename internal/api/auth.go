@@ -0,0 +1,135 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyLocal stores the authenticated key in the request's locals.
+type apiKeyLocal struct{}
+
+// requireAPIKey authenticates the caller by the key sent as a bearer token or
+// in the X-API-Key header, and rejects requests for an account the key does
+// not cover.
+func requireAPIKey(keys *apikey.Service) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key, err := keys.Authenticate(c.UserContext(), presentedKey(c))
+		switch {
+		case errors.Is(err, internal.ErrMissingAPIKey):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing API key"})
+		case errors.Is(err, internal.ErrInvalidAPIKey):
+			logger.WarnContext(c.UserContext(), "Invalid API key rejected")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid API key"})
+		case err != nil:
+			logger.ErrorContext(c.UserContext(), "Failed to authenticate API key", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to authenticate API key"})
+		}
+
+		// Requests without a valid account ID are left to the handlers to reject
+		if accountID, ok := requestAccountID(c); ok && !key.AllowsAccount(accountID) {
+			logger.WarnContext(c.UserContext(), "API key used outside its accounts", "api_key_id", key.ID, "account_id", accountID)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is not allowed to access this account"})
+		}
+
+		c.Locals(apiKeyLocal{}, key)
+		return c.Next()
+	}
+}
+
+// requireSourceType rejects requests for transactions of a Source-Type the
+// authenticated key does not cover, whether submitting or reading them. It
+// runs after requireAPIKey.
+func requireSourceType() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key, ok := c.Locals(apiKeyLocal{}).(*apikey.APIKey)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing API key"})
+		}
+
+		sourceType := transaction.SourceType(c.Get("Source-Type"))
+		if !key.AllowsSourceType(sourceType) {
+			logger.WarnContext(c.UserContext(), "API key used outside its source types", "api_key_id", key.ID, "source_type", sourceType)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is not allowed to access " + string(sourceType) + " transactions"})
+		}
+		return c.Next()
+	}
+}
+
+// requireListedSourceType rejects transaction listings that would include
+// source types the authenticated key does not cover: a key limited to some
+// source types has to filter the listing by one of them. It runs after
+// requireAPIKey.
+func requireListedSourceType() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		key, ok := c.Locals(apiKeyLocal{}).(*apikey.APIKey)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing API key"})
+		}
+
+		sourceType := transaction.SourceType(c.Query("source_type"))
+		if sourceType == "" {
+			for _, st := range []transaction.SourceType{transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment} {
+				if !key.AllowsSourceType(st) {
+					return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is not allowed to access every source type, filter by source_type"})
+				}
+			}
+			return c.Next()
+		}
+		if !key.AllowsSourceType(sourceType) {
+			logger.WarnContext(c.UserContext(), "API key used outside its source types", "api_key_id", key.ID, "source_type", sourceType)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "API key is not allowed to access " + string(sourceType) + " transactions"})
+		}
+		return c.Next()
+	}
+}
+
+// requireAdminToken guards the admin routes with a static token. Without a
+// configured token the admin API is disabled.
+func requireAdminToken(token string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if token == "" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Admin API is disabled"})
+		}
+		if subtle.ConstantTimeCompare([]byte(bearerToken(c)), []byte(token)) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid admin token"})
+		}
+		return c.Next()
+	}
+}
+
+func presentedKey(c fiber.Ctx) string {
+	if key := c.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	return bearerToken(c)
+}
+
+func bearerToken(c fiber.Ctx) string {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// requestAccountID reads the account the same way the handlers do: from the
+// path or the Account-ID header.
+func requestAccountID(c fiber.Ctx) (int64, bool) {
+	raw := c.Params("id")
+	if raw == "" {
+		raw = c.Get("Account-ID")
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	return id, err == nil && id > 0
+}
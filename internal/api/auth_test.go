@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+func TestRequireSourceTypeGuardsReads(t *testing.T) {
+	key := &apikey.APIKey{ID: 1, Name: "slots", SourceTypes: []transaction.SourceType{transaction.SourceTypeGame}}
+	authenticate := func(c fiber.Ctx) error {
+		c.Locals(apiKeyLocal{}, key)
+		return c.Next()
+	}
+
+	// The guards reject the requests before any handler runs
+	app := fiber.New()
+	SetupRoutes(app, nil, nil, nil, nil, nil, Guards{
+		Provider:   []fiber.Handler{authenticate},
+		SourceType: []fiber.Handler{requireSourceType()},
+	})
+
+	for _, path := range []string{
+		"/api/v1/accounts/1/transactions/t1",
+		"/api/v1/transactions/t1",
+		"/api/v1/accounts/1/rounds/r1/transactions",
+		"/api/v1/rounds/r1/transactions",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		req.Header.Set("Source-Type", string(transaction.SourceTypePayment))
+		req.Header.Set("Account-ID", "1")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, path)
+	}
+}
+
+func TestRequireListedSourceType(t *testing.T) {
+	game := &apikey.APIKey{ID: 1, Name: "slots", SourceTypes: []transaction.SourceType{transaction.SourceTypeGame}}
+	every := &apikey.APIKey{ID: 2, Name: "backoffice", SourceTypes: []transaction.SourceType{
+		transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment,
+	}}
+
+	tests := []struct {
+		name  string
+		key   *apikey.APIKey
+		query string
+		want  int
+	}{
+		{"own source type", game, "?source_type=game", fiber.StatusOK},
+		{"other source type", game, "?source_type=payment", fiber.StatusForbidden},
+		{"no filter", game, "", fiber.StatusForbidden},
+		{"no filter with every source type", every, "", fiber.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/transactions", func(c fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			}, func(c fiber.Ctx) error {
+				c.Locals(apiKeyLocal{}, tc.key)
+				return c.Next()
+			}, requireListedSourceType())
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/transactions"+tc.query, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+type AccountHandler struct {
+	accountService *account.Service
+}
+
+func NewAccountHandler(as *account.Service) *AccountHandler {
+	return &AccountHandler{
+		accountService: as,
+	}
+}
+
+func (h *AccountHandler) CreateAccount(c fiber.Ctx) error {
+	acc, err := h.accountService.CreateAccount(c.UserContext())
+	if err != nil {
+		logger.ErrorContext(c.UserContext(), "Failed to create account", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create account"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(acc)
+}
+
+func (h *AccountHandler) GetAccount(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	acc, err := h.accountService.GetAccount(c.UserContext(), accountID)
+	if err != nil {
+		if errors.Is(err, internal.ErrAccountNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Account not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get account"})
+	}
+
+	return c.JSON(acc)
+}
+
+func (h *AccountHandler) GetBalance(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	balance, err := h.accountService.GetBalance(c.UserContext(), accountID)
+	if err != nil {
+		if errors.Is(err, internal.ErrAccountNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Account not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get account balance"})
+	}
+
+	return c.JSON(balance)
+}
+
+func (h *AccountHandler) GetStatement(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if from == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from is required"})
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if to == nil {
+		now := time.Now()
+		to = &now
+	}
+
+	statement, err := h.accountService.GetStatement(c.UserContext(), accountID, *from, *to)
+	if err != nil {
+		switch {
+		case errors.Is(err, internal.ErrInvalidPeriod):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be before to"})
+		case errors.Is(err, internal.ErrAccountNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Account not found"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to build account statement", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build account statement"})
+	}
+
+	return c.JSON(statement)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *apikey.Service
+}
+
+func NewAPIKeyHandler(ks *apikey.Service) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: ks,
+	}
+}
+
+// CreateAPIKey issues a key. The key itself is only part of this response.
+func (h *APIKeyHandler) CreateAPIKey(c fiber.Ctx) error {
+	var key apikey.APIKey
+	if err := c.Bind().JSON(&key); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	secret, err := h.apiKeyService.CreateKey(c.UserContext(), &key)
+	if err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid API key: a name and at least one valid source type are required"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to create API key", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+	}
+
+	logger.InfoContext(c.UserContext(), "API key created", "api_key_id", key.ID, "name", key.Name)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"key":    secret,
+		"apiKey": key,
+	})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c fiber.Ctx) error {
+	keys, err := h.apiKeyService.ListKeys(c.UserContext())
+	if err != nil {
+		logger.ErrorContext(c.UserContext(), "Failed to list API keys", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list API keys"})
+	}
+	if keys == nil {
+		keys = []*apikey.APIKey{}
+	}
+	return c.JSON(fiber.Map{"apiKeys": keys})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid API key ID"})
+	}
+
+	if err := h.apiKeyService.RevokeKey(c.UserContext(), id); err != nil {
+		if errors.Is(err, internal.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "API key not found"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to revoke API key", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke API key"})
+	}
+
+	logger.InfoContext(c.UserContext(), "API key revoked", "api_key_id", id)
+	return c.SendStatus(fiber.StatusNoContent)
+}
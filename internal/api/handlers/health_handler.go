@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// readinessTimeout bounds each readiness check so a hanging database makes
+// the probe fail instead of time out.
+const readinessTimeout = 2 * time.Second
+
+// Pinger checks that the database is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SchemaVersioner reports the applied migration version.
+type SchemaVersioner interface {
+	Version(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+// WorkerStatus reports when a background worker last completed a run.
+type WorkerStatus interface {
+	LastSuccess() time.Time
+}
+
+type HealthHandler struct {
+	db              Pinger
+	schema          SchemaVersioner
+	expectedVersion uint
+	workers         map[string]WorkerStatus
+}
+
+func NewHealthHandler(db Pinger, schema SchemaVersioner, expectedVersion uint, workers map[string]WorkerStatus) *HealthHandler {
+	return &HealthHandler{
+		db:              db,
+		schema:          schema,
+		expectedVersion: expectedVersion,
+		workers:         workers,
+	}
+}
+
+// Readiness reports whether the service can take traffic: the database must
+// answer and be migrated to the version this binary expects. Worker runs are
+// reported but do not affect readiness.
+func (h *HealthHandler) Readiness(c fiber.Ctx) error {
+	ready := true
+
+	database := fiber.Map{"status": "ok"}
+	if err := h.ping(c.UserContext()); err != nil {
+		ready = false
+		database = fiber.Map{"status": "failing", "error": err.Error()}
+	}
+
+	migrations := fiber.Map{"status": "ok", "expected_version": h.expectedVersion}
+	version, dirty, err := h.version(c.UserContext())
+	switch {
+	case err != nil:
+		ready = false
+		migrations["status"] = "failing"
+		migrations["error"] = err.Error()
+	case dirty:
+		ready = false
+		migrations["status"] = "failing"
+		migrations["version"] = version
+		migrations["error"] = fmt.Sprintf("migration %d failed halfway", version)
+	case version != h.expectedVersion:
+		ready = false
+		migrations["status"] = "failing"
+		migrations["version"] = version
+		migrations["error"] = fmt.Sprintf("database is at version %d, expected %d", version, h.expectedVersion)
+	default:
+		migrations["version"] = version
+	}
+
+	workers := fiber.Map{}
+	for name, worker := range h.workers {
+		var lastSuccess *time.Time
+		if t := worker.LastSuccess(); !t.IsZero() {
+			lastSuccess = &t
+		}
+		workers[name] = fiber.Map{"last_success": lastSuccess}
+	}
+
+	status, code := "ready", fiber.StatusOK
+	if !ready {
+		status, code = "not ready", fiber.StatusServiceUnavailable
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status": status,
+		"checks": fiber.Map{
+			"database":   database,
+			"migrations": migrations,
+		},
+		"workers": workers,
+	})
+}
+
+func (h *HealthHandler) ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	return h.db.Ping(ctx)
+}
+
+func (h *HealthHandler) version(ctx context.Context) (uint, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	return h.schema.Version(ctx)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+var errInvalidAccountID = errors.New("invalid account id")
+
+// accountIDFromRequest resolves the account a request targets, either from the
+// `/accounts/:id/...` path parameter or from the `Account-ID` header, and adds
+// it to the request's log context.
+func accountIDFromRequest(c fiber.Ctx) (int64, error) {
+	raw := c.Params("id")
+	if raw == "" {
+		raw = c.Get("Account-ID")
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errInvalidAccountID
+	}
+	c.SetUserContext(logger.WithAccountID(c.UserContext(), id))
+	return id, nil
+}
+
+// parseTransactionFilter reads the listing filters from the query string. The
+// returned error message is safe to show to the client.
+func parseTransactionFilter(c fiber.Ctx) (transaction.Filter, error) {
+	var filter transaction.Filter
+
+	if v := c.Query("source_type"); v != "" {
+		sourceType := transaction.SourceType(v)
+		switch sourceType {
+		case transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment:
+		default:
+			return filter, errors.New("invalid source_type")
+		}
+		filter.SourceType = &sourceType
+	}
+
+	if v := c.Query("state"); v != "" {
+		state := transaction.State(v)
+		switch state {
+		case transaction.StateWin, transaction.StateLost, transaction.StateRollback:
+		default:
+			return filter, errors.New("invalid state")
+		}
+		filter.State = &state
+	}
+
+	if v := c.Query("canceled"); v != "" {
+		canceled, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid canceled flag")
+		}
+		filter.IsCanceled = &canceled
+	}
+
+	var err error
+	if filter.MinAmount, err = queryAmount(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = queryAmount(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return filter, err
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := transaction.DecodeCursor(v)
+		if err != nil {
+			return filter, errors.New("invalid cursor")
+		}
+		filter.After = &cursor
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > transaction.MaxPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", transaction.MaxPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func queryAmount(c fiber.Ctx, param string) (*money.Amount, error) {
+	v := c.Query(param)
+	if v == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", param)
+	}
+	return &amount, nil
+}
+
+func queryTime(c fiber.Ctx, param string) (*time.Time, error) {
+	v := c.Query(param)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, expected an RFC 3339 timestamp", param)
+	}
+	return &t, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+type RoundHandler struct {
+	roundService       *round.Service
+	transactionService *transaction.Service
+}
+
+func NewRoundHandler(rs *round.Service, ts *transaction.Service) *RoundHandler {
+	return &RoundHandler{
+		roundService:       rs,
+		transactionService: ts,
+	}
+}
+
+// ListRoundTransactions returns a round of the account with one page of its
+// transactions, newest first. The listing filters of ListTransactions apply.
+func (h *RoundHandler) ListRoundTransactions(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	// Round IDs are only unique per provider
+	sourceType := transaction.SourceType(c.Get("Source-Type"))
+	switch sourceType {
+	case transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or missing Source-Type"})
+	}
+
+	roundID := c.Params("roundId")
+	rd, err := h.roundService.GetRound(c.UserContext(), accountID, sourceType, roundID)
+	if err != nil {
+		if errors.Is(err, internal.ErrRoundNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Round not found"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to get round", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get round"})
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter.AccountID = accountID
+	filter.SourceType = &sourceType
+	filter.RoundID = &roundID
+
+	page, err := h.transactionService.ListTransactions(c.UserContext(), filter)
+	if err != nil {
+		logger.ErrorContext(c.UserContext(), "Failed to list round transactions", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list transactions"})
+	}
+
+	transactions := page.Transactions
+	if transactions == nil {
+		transactions = []*transaction.Transaction{}
+	}
+	response := fiber.Map{"round": rd, "transactions": transactions, "next_cursor": nil}
+	if page.NextCursor != nil {
+		response["next_cursor"] = page.NextCursor.Encode()
+	}
+
+	return c.JSON(response)
+}
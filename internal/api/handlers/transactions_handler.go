@@ -2,81 +2,260 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 
-	"github.com/blackcloro/transaction-processor/pkg/logger"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
 
 	"github.com/blackcloro/transaction-processor/internal"
-	"github.com/gofiber/fiber/v3"
-	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/blackcloro/transaction-processor/internal/metrics"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
 
-	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/processing"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
 )
 
+// idempotentReplayedHeader marks a response that repeats the result of an
+// earlier request with the same transaction.
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
 type TransactionHandler struct {
-	accountService     *account.Service
+	processingService  *processing.Service
 	transactionService *transaction.Service
-	db                 *pgxpool.Pool
 }
 
-func NewTransactionHandler(as *account.Service, ts *transaction.Service, db *pgxpool.Pool) *TransactionHandler {
+func NewTransactionHandler(ps *processing.Service, ts *transaction.Service) *TransactionHandler {
 	return &TransactionHandler{
-		accountService:     as,
+		processingService:  ps,
 		transactionService: ts,
-		db:                 db,
 	}
 }
 
 func (h *TransactionHandler) CreateTransaction(c fiber.Ctx) error {
 	var tx transaction.Transaction
 	if err := c.Bind().JSON(&tx); err != nil {
+		if errors.Is(err, internal.ErrAmountPrecision) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount has too many fractional digits"})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
 	tx.SourceType = transaction.SourceType(c.Get("Source-Type"))
-	tx.AccountID = 1
+	tx.AccountID = accountID
+	c.SetUserContext(logger.WithTransactionID(c.UserContext(), tx.TransactionID))
 
-	// Start a database transaction
-	dbTx, err := h.db.Begin(c.Context())
+	// Record the transaction and update the balance atomically
+	result, err := h.processingService.ProcessTransaction(c.UserContext(), &tx)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to start transaction"})
+		var validationErrs validator.ValidationErrors
+		var duplicate *transaction.DuplicateError
+		switch {
+		case errors.As(err, &validationErrs):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid transaction"})
+		case errors.Is(err, internal.ErrAccountNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Account not found"})
+		case errors.Is(err, internal.ErrInsufficientFunds):
+			metrics.TransactionRejected(string(tx.SourceType), metrics.ReasonInsufficientFunds)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Insufficient funds"})
+		case errors.As(err, &duplicate):
+			metrics.TransactionRejected(string(tx.SourceType), metrics.ReasonDuplicate)
+			logger.WarnContext(c.UserContext(), "Duplicate transaction rejected", "differences", len(duplicate.Diff))
+			if len(duplicate.Diff) == 0 {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Duplicate transaction"})
+			}
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Duplicate transaction with a different payload",
+				"diff":  duplicate.Diff,
+			})
+		case errors.Is(err, internal.ErrDuplicateTransaction):
+			metrics.TransactionRejected(string(tx.SourceType), metrics.ReasonDuplicate)
+			logger.WarnContext(c.UserContext(), "Duplicate transaction rejected")
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Duplicate transaction"})
+		case errors.Is(err, internal.ErrNumericOverflow):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount out of range"})
+		case errors.Is(err, internal.ErrOriginalNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Original transaction not found"})
+		case errors.Is(err, internal.ErrAlreadyReversed):
+			metrics.TransactionRejected(string(tx.SourceType), metrics.ReasonAlreadyReversed)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Original transaction was already reversed"})
+		case errors.Is(err, internal.ErrRollbackMismatch):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "Rollback does not match the original transaction"})
+		case errors.Is(err, internal.ErrRoundNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Round not found"})
+		case errors.Is(err, internal.ErrRoundClosed):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Round is closed"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to process transaction", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process transaction"})
 	}
-	defer dbTx.Rollback(c.Context()) // Rollback in case of error
 
-	// Check current balance
-	currentBalance, err := h.accountService.GetBalance(c.Context(), 1) // Assuming single account with ID 1
+	if result.Replayed {
+		metrics.TransactionReplayed(string(tx.SourceType))
+		logger.InfoContext(c.UserContext(), "Duplicate transaction replayed")
+		c.Set(idempotentReplayedHeader, "true")
+	} else {
+		metrics.TransactionProcessed(string(tx.SourceType), string(tx.State))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":     "Transaction processed successfully",
+		"balance":     result.Balance,
+		"transaction": result.Transaction,
+	})
+}
+
+type batchRequest struct {
+	Mode         string                     `json:"mode"`
+	Transactions []*transaction.Transaction `json:"transactions"`
+}
+
+// CreateBatch processes up to processing.MaxBatchSize transactions of one
+// account and provider, all or nothing in atomic mode (the default) or one by
+// one in best_effort mode, and reports the outcome of each.
+func (h *TransactionHandler) CreateBatch(c fiber.Ctx) error {
+	var req batchRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		if errors.Is(err, internal.ErrAmountPrecision) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount has too many fractional digits"})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	mode, err := processing.ParseBatchMode(req.Mode)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get account balance"})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid mode, expected atomic or best_effort"})
 	}
 
-	// Check for sufficient funds if it's a "lost" transaction
-	if tx.State == transaction.StateLost && currentBalance < tx.Amount {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Insufficient funds"})
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
 	}
 
-	// Create the transaction
-	if err := h.transactionService.CreateTransaction(c.Context(), &tx); err != nil {
-		logger.Warn(err.Error())
-		if errors.Is(err, internal.ErrDuplicateTransaction) {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Duplicate transaction"})
+	sourceType := transaction.SourceType(c.Get("Source-Type"))
+	for i, tx := range req.Transactions {
+		if tx == nil {
+			req.Transactions[i] = &transaction.Transaction{}
+			tx = req.Transactions[i]
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create transaction"})
+		tx.SourceType = sourceType
+		tx.AccountID = accountID
 	}
 
-	// Process the transaction (update balance)
-	newBalance, err := h.accountService.ProcessTransaction(c.Context(), 1, &tx) // Assuming single account with ID 1
+	result, err := h.processingService.ProcessBatch(c.UserContext(), mode, req.Transactions)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process transaction"})
+		switch {
+		case errors.Is(err, internal.ErrEmptyBatch):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Batch has no transactions"})
+		case errors.Is(err, internal.ErrBatchTooLarge):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("Batch has more than %d transactions", processing.MaxBatchSize)})
+		case errors.Is(err, internal.ErrAccountNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Account not found"})
+		case errors.Is(err, internal.ErrNumericOverflow):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Amount out of range"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to process batch", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process batch"})
 	}
 
-	// Commit the database transaction
-	if err := dbTx.Commit(c.Context()); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to commit transaction"})
+	summary := make(map[processing.ItemStatus]int)
+	for _, item := range result.Items {
+		summary[item.Status]++
+		if !result.Committed {
+			continue
+		}
+		switch item.Status {
+		case processing.ItemCreated:
+			metrics.TransactionProcessed(string(sourceType), string(item.Transaction.State))
+		case processing.ItemDuplicate:
+			if item.Transaction != nil {
+				metrics.TransactionReplayed(string(sourceType))
+			} else {
+				metrics.TransactionRejected(string(sourceType), metrics.ReasonDuplicate)
+			}
+		case processing.ItemInsufficientFunds:
+			metrics.TransactionRejected(string(sourceType), metrics.ReasonInsufficientFunds)
+		}
 	}
+	logger.InfoContext(c.UserContext(), "Batch processed",
+		"mode", string(mode), "size", len(result.Items), "committed", result.Committed, "created", summary[processing.ItemCreated])
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message":     "Transaction processed successfully",
-		"balance":     newBalance,
-		"transaction": tx,
+	status := fiber.StatusOK
+	switch {
+	case mode == processing.BatchAtomic && result.Committed:
+		status = fiber.StatusCreated
+	case mode == processing.BatchAtomic:
+		status = fiber.StatusUnprocessableEntity
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"mode":      result.Mode,
+		"committed": result.Committed,
+		"balance":   result.Balance,
+		"summary":   summary,
+		"results":   result.Items,
 	})
 }
+
+func (h *TransactionHandler) ListTransactions(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	filter.AccountID = accountID
+
+	page, err := h.transactionService.ListTransactions(c.UserContext(), filter)
+	if err != nil {
+		logger.ErrorContext(c.UserContext(), "Failed to list transactions", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to list transactions"})
+	}
+
+	transactions := page.Transactions
+	if transactions == nil {
+		transactions = []*transaction.Transaction{}
+	}
+	response := fiber.Map{"transactions": transactions, "next_cursor": nil}
+	if page.NextCursor != nil {
+		response["next_cursor"] = page.NextCursor.Encode()
+	}
+
+	return c.JSON(response)
+}
+
+func (h *TransactionHandler) GetTransaction(c fiber.Ctx) error {
+	accountID, err := accountIDFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid account ID"})
+	}
+
+	// Transaction IDs are only unique per provider
+	sourceType := transaction.SourceType(c.Get("Source-Type"))
+	switch sourceType {
+	case transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or missing Source-Type"})
+	}
+
+	transactionID := c.Params("transactionId")
+	c.SetUserContext(logger.WithTransactionID(c.UserContext(), transactionID))
+
+	tx, err := h.transactionService.GetTransaction(c.UserContext(), accountID, sourceType, transactionID)
+	if err != nil {
+		if errors.Is(err, internal.ErrTransactionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Transaction not found"})
+		}
+		logger.ErrorContext(c.UserContext(), "Failed to get transaction", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get transaction"})
+	}
+
+	return c.JSON(tx)
+}
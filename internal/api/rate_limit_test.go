@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
+)
+
+func TestRateLimitSharesBucketOfUnknownSourceTypes(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Policies{
+		Default: ratelimit.Policy{Limit: 1, Window: time.Minute},
+	})
+	app := fiber.New()
+	app.Get("/accounts", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}, rateLimit(limiter))
+
+	get := func(sourceType string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/accounts", nil)
+		req.Header.Set("Source-Type", sourceType)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, get("made-up-1"))
+	assert.Equal(t, fiber.StatusTooManyRequests, get("made-up-2"), "made-up source types must not open fresh buckets")
+	assert.Equal(t, fiber.StatusOK, get("game"), "known source types have buckets of their own")
+}
+
+func TestRateLimitClientRunsBeforeAuthentication(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Policies{
+		Client: ratelimit.Policy{Limit: 2, Window: time.Minute},
+	})
+	app := fiber.New()
+	app.Get("/admin/api-keys", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}, rateLimitClient(limiter), requireAdminToken("admin"))
+
+	get := func(token string) int {
+		req := httptest.NewRequest(fiber.MethodGet, "/admin/api-keys", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusUnauthorized, get("guess-1"))
+	assert.Equal(t, fiber.StatusOK, get("admin"))
+	assert.Equal(t, fiber.StatusTooManyRequests, get("guess-2"), "failed attempts count against the client")
+}
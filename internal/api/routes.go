@@ -1,15 +1,71 @@
 package api
 
 import (
+	"slices"
+
 	"github.com/blackcloro/transaction-processor/internal/api/handlers"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/healthcheck"
 )
 
-func SetupRoutes(app *fiber.App, th *handlers.TransactionHandler) {
+// Guards holds the middleware that runs ahead of the handlers of each kind
+// of route.
+type Guards struct {
+	// Provider runs before every account and transaction route, e.g. to
+	// authenticate the caller.
+	Provider []fiber.Handler
+	// SourceType runs after Provider on the routes that act on the
+	// transactions of the provider named in the Source-Type header, e.g. to
+	// check the caller may access that provider's transactions.
+	SourceType []fiber.Handler
+	// Listing runs after Provider on the transaction listings, e.g. to keep
+	// the caller to the source types it may access.
+	Listing []fiber.Handler
+	// Submission runs after SourceType before transactions are submitted,
+	// e.g. to verify the provider's signature.
+	Submission []fiber.Handler
+	// Admin runs before the admin routes.
+	Admin []fiber.Handler
+}
+
+func SetupRoutes(app *fiber.App, th *handlers.TransactionHandler, ah *handlers.AccountHandler, hh *handlers.HealthHandler, kh *handlers.APIKeyHandler, rh *handlers.RoundHandler, guards Guards) {
 	api := app.Group("/api/v1")
+	provider := chain(guards.Provider)
+	listing := chain(guards.Provider, guards.Listing)
+	sourceType := chain(guards.Provider, guards.SourceType)
+	submission := chain(guards.Provider, guards.SourceType, guards.Submission)
+	admin := chain(guards.Admin)
+
+	api.Post("/accounts", ah.CreateAccount, provider...)
+	api.Get("/accounts/:id", ah.GetAccount, provider...)
+	api.Get("/accounts/:id/balance", ah.GetBalance, provider...)
+	api.Get("/accounts/:id/statement", ah.GetStatement, provider...)
+
+	// The account is taken from the path or, on the flat route, from the Account-ID header.
+	api.Post("/accounts/:id/transactions", th.CreateTransaction, submission...)
+	api.Post("/transactions", th.CreateTransaction, submission...)
+	api.Post("/accounts/:id/transactions/batch", th.CreateBatch, submission...)
+	api.Post("/transactions/batch", th.CreateBatch, submission...)
+	api.Get("/accounts/:id/transactions", th.ListTransactions, listing...)
+	api.Get("/transactions", th.ListTransactions, listing...)
+	api.Get("/accounts/:id/transactions/:transactionId", th.GetTransaction, sourceType...)
+	api.Get("/transactions/:transactionId", th.GetTransaction, sourceType...)
+	api.Get("/accounts/:id/rounds/:roundId/transactions", rh.ListRoundTransactions, sourceType...)
+	api.Get("/rounds/:roundId/transactions", rh.ListRoundTransactions, sourceType...)
+
+	api.Post("/admin/api-keys", kh.CreateAPIKey, admin...)
+	api.Get("/admin/api-keys", kh.ListAPIKeys, admin...)
+	api.Delete("/admin/api-keys/:id", kh.RevokeAPIKey, admin...)
 
-	api.Post("/transactions", th.CreateTransaction)
 	// Check if the server is up and running.
 	api.Get(healthcheck.DefaultLivenessEndpoint, healthcheck.NewHealthChecker())
+	// Check if the server can take traffic: database reachable and migrated.
+	api.Get("/readyz", hh.Readiness)
+}
+
+// chain joins guards into the middleware of a route. Fiber appends the route's
+// handler to the middleware it is given, so the result has no spare capacity
+// for routes sharing it to write their handlers into.
+func chain(guards ...[]fiber.Handler) []fiber.Handler {
+	return slices.Clip(slices.Concat(guards...))
 }
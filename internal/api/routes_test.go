@@ -0,0 +1,57 @@
+package api
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/blackcloro/transaction-processor/internal/config"
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
+	"github.com/blackcloro/transaction-processor/internal/signing"
+)
+
+func TestRoutesRunTheirOwnHandler(t *testing.T) {
+	cfg := &config.Config{
+		Auth:    config.AuthConfig{Enabled: true, AdminToken: "admin"},
+		Signing: config.SigningConfig{Enabled: true},
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Policies{Default: ratelimit.Policy{Limit: 1, Window: time.Minute}})
+	verifier := signing.NewVerifier(map[string]string{"game": "secret"}, time.Minute, signing.NewMemoryStore())
+	// Only the wiring is inspected, no handler runs
+	server := NewServer(cfg, nil, nil, nil, nil, nil, nil, limiter, verifier)
+
+	want := map[string]string{
+		"POST /api/v1/accounts":                                 "AccountHandler).CreateAccount",
+		"GET /api/v1/accounts/:id":                              "AccountHandler).GetAccount",
+		"GET /api/v1/accounts/:id/balance":                      "AccountHandler).GetBalance",
+		"GET /api/v1/accounts/:id/statement":                    "AccountHandler).GetStatement",
+		"POST /api/v1/accounts/:id/transactions":                "TransactionHandler).CreateTransaction",
+		"POST /api/v1/transactions":                             "TransactionHandler).CreateTransaction",
+		"POST /api/v1/accounts/:id/transactions/batch":          "TransactionHandler).CreateBatch",
+		"POST /api/v1/transactions/batch":                       "TransactionHandler).CreateBatch",
+		"GET /api/v1/accounts/:id/transactions":                 "TransactionHandler).ListTransactions",
+		"GET /api/v1/transactions":                              "TransactionHandler).ListTransactions",
+		"GET /api/v1/accounts/:id/transactions/:transactionId":  "TransactionHandler).GetTransaction",
+		"GET /api/v1/transactions/:transactionId":               "TransactionHandler).GetTransaction",
+		"GET /api/v1/accounts/:id/rounds/:roundId/transactions": "RoundHandler).ListRoundTransactions",
+		"GET /api/v1/rounds/:roundId/transactions":              "RoundHandler).ListRoundTransactions",
+		"POST /api/v1/admin/api-keys":                           "APIKeyHandler).CreateAPIKey",
+		"GET /api/v1/admin/api-keys":                            "APIKeyHandler).ListAPIKeys",
+		"DELETE /api/v1/admin/api-keys/:id":                     "APIKeyHandler).RevokeAPIKey",
+	}
+
+	for _, route := range server.app.GetRoutes(true) {
+		handler, ok := want[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		name := runtime.FuncForPC(reflect.ValueOf(route.Handlers[len(route.Handlers)-1]).Pointer()).Name()
+		assert.True(t, strings.HasSuffix(name, handler+"-fm"), "%s %s runs %s", route.Method, route.Path, name)
+		delete(want, route.Method+" "+route.Path)
+	}
+	assert.Empty(t, want, "routes not registered")
+}
@@ -3,15 +3,17 @@ package api
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/blackcloro/transaction-processor/internal/api/handlers"
 	"github.com/blackcloro/transaction-processor/internal/config"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/metrics"
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
+	"github.com/blackcloro/transaction-processor/internal/signing"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
 	"github.com/blackcloro/transaction-processor/pkg/logger"
 
 	"github.com/gofiber/fiber/v3"
-	"github.com/gofiber/fiber/v3/middleware/limiter"
-	fiberLogger "github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/gofiber/fiber/v3/middleware/recover"
 )
 
@@ -19,32 +21,58 @@ type Server struct {
 	app                *fiber.App
 	config             *config.Config
 	transactionHandler *handlers.TransactionHandler
+	accountHandler     *handlers.AccountHandler
+	healthHandler      *handlers.HealthHandler
+	apiKeyHandler      *handlers.APIKeyHandler
+	roundHandler       *handlers.RoundHandler
 }
 
-func NewServer(cfg *config.Config, th *handlers.TransactionHandler) *Server {
+func NewServer(cfg *config.Config, th *handlers.TransactionHandler, ah *handlers.AccountHandler, hh *handlers.HealthHandler, kh *handlers.APIKeyHandler, rh *handlers.RoundHandler, keys *apikey.Service, limiter *ratelimit.Limiter, verifier *signing.Verifier) *Server {
 	app := fiber.New()
-	app.Use(fiberLogger.New())
+	app.Use(accessLog())
 	app.Use(recover.New())
-	app.Use(limiter.New(limiter.Config{
-		Max:               20,
-		Expiration:        30 * time.Second,
-		LimiterMiddleware: limiter.SlidingWindow{},
-	}))
+	app.Use(metrics.Middleware())
+	app.Use(tracing.Middleware())
+	app.Get("/metrics", metrics.Handler())
 
 	server := &Server{
 		app:                app,
 		config:             cfg,
 		transactionHandler: th,
+		accountHandler:     ah,
+		healthHandler:      hh,
+		apiKeyHandler:      kh,
+		roundHandler:       rh,
 	}
 
-	SetupRoutes(app, th)
+	var guards Guards
+	// Clients are limited before they authenticate, so failed attempts count too
+	if limiter != nil {
+		guards.Provider = append(guards.Provider, rateLimitClient(limiter))
+		guards.Admin = append(guards.Admin, rateLimitClient(limiter))
+	}
+	guards.Admin = append(guards.Admin, requireAdminToken(cfg.Auth.AdminToken))
+	if cfg.Auth.Enabled {
+		guards.Provider = append(guards.Provider, requireAPIKey(keys))
+		guards.SourceType = append(guards.SourceType, requireSourceType())
+		guards.Listing = append(guards.Listing, requireListedSourceType())
+	}
+	// Limits apply per key, so they are counted once the caller is known
+	if limiter != nil {
+		guards.Provider = append(guards.Provider, rateLimit(limiter))
+	}
+	if verifier != nil {
+		guards.Submission = append(guards.Submission, requireSignature(verifier))
+	}
+
+	SetupRoutes(app, th, ah, hh, kh, rh, guards)
 
 	return server
 }
 
 func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%d", s.config.Port)
-	logger.Info("Starting server at address", addr)
+	logger.Info("Starting server", "addr", addr)
 	err := s.app.Listen(addr)
 	if err != nil {
 		return err
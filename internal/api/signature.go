@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/signing"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+const (
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// signatureErrors maps verification failures to the message returned to the
+// provider, so a misconfigured client can tell what to fix.
+var signatureErrors = map[error]string{
+	internal.ErrMissingSignature: "Missing X-Signature header",
+	internal.ErrMissingTimestamp: "Missing X-Signature-Timestamp header",
+	internal.ErrInvalidTimestamp: "X-Signature-Timestamp must be a unix time in seconds",
+	internal.ErrStaleTimestamp:   "X-Signature-Timestamp is outside the allowed window",
+	internal.ErrUnknownProvider:  "Unknown Source-Type for signed requests",
+	internal.ErrInvalidSignature: "Invalid signature",
+	internal.ErrReplayedRequest:  "Request was already received, sign it again with a new timestamp to resend it",
+}
+
+// requireSignature rejects requests that are not signed by the provider named
+// in their Source-Type header, or that were already accepted. A request the
+// handler fails is released, so the provider can retry it as is.
+func requireSignature(verifier *signing.Verifier) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		provider, signature := c.Get("Source-Type"), c.Get(signatureHeader)
+		err := verifier.Verify(c.UserContext(), provider, signature, signing.Request{
+			Timestamp: c.Get(signatureTimestampHeader),
+			Method:    c.Method(),
+			Path:      c.Path(),
+			AccountID: c.Get("Account-ID"),
+			Body:      c.Body(),
+		})
+		if err == nil {
+			err = c.Next()
+			if status := c.Response().StatusCode(); err != nil || status < 200 || status >= 300 {
+				if err := verifier.Release(c.UserContext(), provider, signature); err != nil {
+					logger.ErrorContext(c.UserContext(), "Failed to release request signature", err)
+				}
+			}
+			return err
+		}
+
+		for target, message := range signatureErrors {
+			if errors.Is(err, target) {
+				logger.WarnContext(c.UserContext(), "Request signature rejected", "reason", err.Error())
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": message})
+			}
+		}
+		return err
+	}
+}
@@ -5,29 +5,60 @@ import (
 
 	"github.com/blackcloro/transaction-processor/internal"
 
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
 )
 
+// Account holds a balance. Version is the optimistic concurrency token: it is
+// bumped by the repository on every successful update.
 type Account struct {
-	ID        int64
-	Balance   float64
-	Version   int
-	UpdatedAt time.Time
+	ID        int64        `json:"id"`
+	Balance   money.Amount `json:"balance"`
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
 }
 
 func (a *Account) ApplyTransaction(tx *transaction.Transaction) error {
+	if tx.AccountID != a.ID {
+		return internal.ErrAccountMismatch
+	}
+
 	switch tx.State {
 	case transaction.StateWin:
-		a.Balance += tx.Amount
+		a.Balance = a.Balance.Add(tx.Amount)
 	case transaction.StateLost:
-		if a.Balance < tx.Amount {
+		if a.Balance.LessThan(tx.Amount) {
 			return internal.ErrInsufficientFunds
 		}
-		a.Balance -= tx.Amount
+		a.Balance = a.Balance.Sub(tx.Amount)
 	default:
 		return internal.ErrInvalidTransactionState
 	}
-	a.Version++
 	a.UpdatedAt = time.Now()
 	return nil
 }
+
+// ReverseTransaction undoes the effect of a previously applied transaction on
+// the balance. If the reversal would overdraw the account, policy decides:
+// OverdraftSkip returns internal.ErrInsufficientFunds and leaves the balance
+// untouched, the other policies reverse down to zero and return the
+// unreversed rest as shortfall.
+func (a *Account) ReverseTransaction(tx *transaction.Transaction, policy transaction.OverdraftPolicy) (reversed, shortfall money.Amount, err error) {
+	if tx.AccountID != a.ID {
+		return money.Zero, money.Zero, internal.ErrAccountMismatch
+	}
+
+	reversed = tx.BalanceChange().Neg()
+	if a.Balance.Add(reversed).IsNegative() {
+		if policy == transaction.OverdraftSkip {
+			return money.Zero, money.Zero, internal.ErrInsufficientFunds
+		}
+		shortfall = a.Balance.Add(reversed).Neg()
+		reversed = a.Balance.Neg()
+	}
+
+	a.Balance = a.Balance.Add(reversed)
+	a.UpdatedAt = time.Now()
+	return reversed, shortfall, nil
+}
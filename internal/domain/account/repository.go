@@ -2,9 +2,22 @@ package account
 
 import (
 	"context"
+	"time"
 )
 
 type Repository interface {
+	Create(ctx context.Context, account *Account) error
 	GetByID(ctx context.Context, id int64) (*Account, error)
+	// List returns every account ordered by id.
+	List(ctx context.Context) ([]*Account, error)
+	// GetByIDForUpdate loads the account and locks it until the surrounding
+	// unit of work ends.
+	GetByIDForUpdate(ctx context.Context, id int64) (*Account, error)
+	// Update persists the account if its version is unchanged since it was
+	// read and returns internal.ErrConcurrentModification otherwise.
 	Update(ctx context.Context, account *Account) error
+	// GetWithMovements returns the account and its balance movements that
+	// occurred at or after since, oldest first. Both are read from one
+	// snapshot, so the balance includes exactly the movements returned.
+	GetWithMovements(ctx context.Context, id int64, since time.Time) (*Account, []Movement, error)
 }
@@ -2,10 +2,18 @@ package account
 
 import (
 	"context"
+	"time"
 
-	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/blackcloro/transaction-processor/internal/domain/account")
+
 type Service struct {
 	repo Repository
 }
@@ -14,27 +22,84 @@ func NewService(repo Repository) *Service {
 	return &Service{repo: repo}
 }
 
-func (s *Service) ProcessTransaction(ctx context.Context, accountID int64, tx *transaction.Transaction) (float64, error) {
+func (s *Service) CreateAccount(ctx context.Context) (*Account, error) {
+	account := &Account{}
+	if err := s.repo.Create(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *Service) GetAccount(ctx context.Context, accountID int64) (*Account, error) {
+	return s.repo.GetByID(ctx, accountID)
+}
+
+func (s *Service) ListAccounts(ctx context.Context) ([]*Account, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) GetBalance(ctx context.Context, accountID int64) (*Balance, error) {
 	account, err := s.repo.GetByID(ctx, accountID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	return &Balance{
+		AccountID: account.ID,
+		Balance:   account.Balance,
+		Version:   account.Version,
+		UpdatedAt: account.UpdatedAt,
+	}, nil
+}
 
-	if err := account.ApplyTransaction(tx); err != nil {
-		return account.Balance, err
+// GetStatement builds the account statement for [from, to). The opening
+// balance is derived backwards from the current balance, so it matches what
+// the account actually held at from.
+func (s *Service) GetStatement(ctx context.Context, accountID int64, from, to time.Time) (_ *Statement, err error) {
+	ctx, span := tracer.Start(ctx, "account.GetStatement", trace.WithAttributes(
+		attribute.Int64("account.id", accountID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if !from.Before(to) {
+		return nil, internal.ErrInvalidPeriod
 	}
 
-	if err := s.repo.Update(ctx, account); err != nil {
-		return account.Balance, err
+	account, movements, err := s.repo.GetWithMovements(ctx, accountID, from)
+	if err != nil {
+		return nil, err
 	}
 
-	return account.Balance, nil
-}
+	opening := account.Balance
+	for _, m := range movements {
+		opening = opening.Sub(m.Change)
+	}
 
-func (s *Service) GetBalance(ctx context.Context, accountID int64) (float64, error) {
-	account, err := s.repo.GetByID(ctx, accountID)
-	if err != nil {
-		return 0, err
+	statement := &Statement{
+		AccountID:      accountID,
+		From:           from,
+		To:             to,
+		OpeningBalance: opening,
+		Entries:        []StatementEntry{},
+	}
+
+	running := opening
+	for _, m := range movements {
+		if !m.OccurredAt.Before(to) {
+			break
+		}
+		running = running.Add(m.Change)
+		statement.Entries = append(statement.Entries, StatementEntry{
+			TransactionID: m.TransactionID,
+			SourceType:    m.SourceType,
+			State:         m.State,
+			Kind:          m.Kind,
+			Amount:        m.Amount,
+			Change:        m.Change,
+			Balance:       running,
+			OccurredAt:    m.OccurredAt,
+		})
 	}
-	return account.Balance, nil
+	statement.ClosingBalance = running
+
+	return statement, nil
 }
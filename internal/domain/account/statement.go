@@ -0,0 +1,64 @@
+package account
+
+import (
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+// Balance is a point-in-time view of an account's balance.
+type Balance struct {
+	AccountID int64        `json:"account_id"`
+	Balance   money.Amount `json:"balance"`
+	Version   int          `json:"version"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type MovementKind string
+
+const (
+	// MovementApplied is a transaction being applied to the balance.
+	MovementApplied MovementKind = "applied"
+	// MovementCanceled is the reversal of a previously applied transaction.
+	MovementCanceled MovementKind = "canceled"
+	// MovementOpening is a balance carried over from before the ledger existed.
+	MovementOpening MovementKind = "opening"
+	// MovementAdjustment is a correction booked by the balance reconciler.
+	MovementAdjustment MovementKind = "adjustment"
+)
+
+// Movement is a single change of an account's balance. Transaction details
+// are empty for movements not caused by a transaction.
+type Movement struct {
+	TransactionID string
+	SourceType    transaction.SourceType
+	State         transaction.State
+	Amount        money.Amount
+	Kind          MovementKind
+	// Change is the signed effect of the movement on the balance.
+	Change     money.Amount
+	OccurredAt time.Time
+}
+
+type StatementEntry struct {
+	TransactionID string                 `json:"transaction_id,omitempty"`
+	SourceType    transaction.SourceType `json:"source_type,omitempty"`
+	State         transaction.State      `json:"state,omitempty"`
+	Kind          MovementKind           `json:"kind"`
+	Amount        money.Amount           `json:"amount"`
+	Change        money.Amount           `json:"change"`
+	Balance       money.Amount           `json:"balance"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+}
+
+// Statement lists every balance movement of an account within [From, To)
+// together with the running balance after each of them.
+type Statement struct {
+	AccountID      int64            `json:"account_id"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance money.Amount     `json:"opening_balance"`
+	ClosingBalance money.Amount     `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
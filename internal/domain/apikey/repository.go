@@ -0,0 +1,16 @@
+package apikey
+
+import "context"
+
+type Repository interface {
+	// Create stores the key under hash and sets its ID and creation time.
+	Create(ctx context.Context, key *APIKey, hash []byte) error
+	// GetByHash returns the unrevoked key stored under hash, or
+	// internal.ErrInvalidAPIKey.
+	GetByHash(ctx context.Context, hash []byte) (*APIKey, error)
+	// List returns every key, revoked ones included, ordered by id.
+	List(ctx context.Context) ([]*APIKey, error)
+	// Revoke revokes the key, or returns internal.ErrAPIKeyNotFound if there is
+	// no such unrevoked key.
+	Revoke(ctx context.Context, id int64) error
+}
@@ -0,0 +1,50 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// CreateKey issues a new key with the name and permissions of key and
+// returns it in clear. This is the only time the clear key is available.
+func (s *Service) CreateKey(ctx context.Context, key *APIKey) (string, error) {
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+
+	secret, err := generate()
+	if err != nil {
+		return "", err
+	}
+	key.Prefix = secret[:displayLength]
+
+	if err := s.repo.Create(ctx, key, Hash(secret)); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Authenticate returns the key presented by a caller.
+func (s *Service) Authenticate(ctx context.Context, secret string) (*APIKey, error) {
+	if secret == "" {
+		return nil, internal.ErrMissingAPIKey
+	}
+	return s.repo.GetByHash(ctx, Hash(secret))
+}
+
+func (s *Service) ListKeys(ctx context.Context) ([]*APIKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *Service) RevokeKey(ctx context.Context, id int64) error {
+	return s.repo.Revoke(ctx, id)
+}
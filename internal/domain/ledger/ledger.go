@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+type Direction string
+
+const (
+	Debit  Direction = "debit"
+	Credit Direction = "credit"
+)
+
+// Book separates the two sides of every entry: the player's funds and the
+// house that is the counterparty of each win and loss.
+type Book string
+
+const (
+	BookPlayer Book = "player"
+	BookHouse  Book = "house"
+)
+
+type Reason string
+
+const (
+	// ReasonApply records a transaction being applied to the balance.
+	ReasonApply Reason = "apply"
+	// ReasonCancel records the reversal of a canceled transaction.
+	ReasonCancel Reason = "cancel"
+	// ReasonOpening carries a balance that predates the ledger.
+	ReasonOpening Reason = "opening"
+	// ReasonAdjustment corrects postings during reconciliation.
+	ReasonAdjustment Reason = "adjustment"
+)
+
+// Posting is a single, immutable debit or credit on one book of an account.
+type Posting struct {
+	ID        int64        `json:"id"`
+	EntryID   int64        `json:"entry_id"`
+	AccountID int64        `json:"account_id"`
+	Book      Book         `json:"book"`
+	Direction Direction    `json:"direction"`
+	Amount    money.Amount `json:"amount"`
+	// TransactionRef is the internal id of the transaction that caused the
+	// posting, if any.
+	TransactionRef *int64    `json:"transaction_ref"`
+	Reason         Reason    `json:"reason"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Change returns the signed effect of the posting on its book's balance.
+func (p Posting) Change() money.Amount {
+	if p.Direction == Debit {
+		return p.Amount.Neg()
+	}
+	return p.Amount
+}
+
+// Entry is a balanced set of postings recorded together.
+type Entry struct {
+	ID       int64
+	Postings []Posting
+}
+
+// NewEntry builds the entry for a signed change of an account's balance: a
+// positive change credits the player book and debits the house book, a
+// negative one does the opposite.
+func NewEntry(accountID int64, transactionRef *int64, reason Reason, change money.Amount) Entry {
+	player, house := Credit, Debit
+	if change.IsNegative() {
+		player, house = Debit, Credit
+		change = change.Neg()
+	}
+
+	return Entry{
+		Postings: []Posting{
+			{AccountID: accountID, Book: BookPlayer, Direction: player, Amount: change, TransactionRef: transactionRef, Reason: reason},
+			{AccountID: accountID, Book: BookHouse, Direction: house, Amount: change, TransactionRef: transactionRef, Reason: reason},
+		},
+	}
+}
+
+// Validate checks the double-entry invariant: debits equal credits.
+func (e Entry) Validate() error {
+	debits, credits := money.Zero, money.Zero
+	for _, p := range e.Postings {
+		if p.Amount.IsNegative() {
+			return internal.ErrUnbalancedEntry
+		}
+		switch p.Direction {
+		case Debit:
+			debits = debits.Add(p.Amount)
+		case Credit:
+			credits = credits.Add(p.Amount)
+		default:
+			return internal.ErrUnbalancedEntry
+		}
+	}
+	if len(e.Postings) == 0 || debits != credits {
+		return internal.ErrUnbalancedEntry
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+type Repository interface {
+	// Append stores the entry's postings and assigns the entry and posting ids.
+	Append(ctx context.Context, entry *Entry) error
+	// AppendMany stores the postings of all entries in one statement.
+	AppendMany(ctx context.Context, entries []*Entry) error
+	// Balance derives the player balance of the account from its postings.
+	Balance(ctx context.Context, accountID int64) (money.Amount, error)
+}
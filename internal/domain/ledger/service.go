@@ -0,0 +1,69 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+type Service struct {
+	repo     Repository
+	accounts account.Repository
+}
+
+func NewService(repo Repository, accounts account.Repository) *Service {
+	return &Service{repo: repo, accounts: accounts}
+}
+
+// Verification compares the stored balance of an account with the balance
+// derived from its ledger.
+type Verification struct {
+	AccountID int64        `json:"account_id"`
+	Stored    money.Amount `json:"stored"`
+	Derived   money.Amount `json:"derived"`
+}
+
+func (v Verification) Balanced() bool {
+	return v.Stored == v.Derived
+}
+
+func (s *Service) Verify(ctx context.Context, accountID int64) (*Verification, error) {
+	acc, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := s.repo.Balance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verification{AccountID: accountID, Stored: acc.Balance, Derived: derived}, nil
+}
+
+// Rebuild overwrites the stored balance of the account with the balance
+// derived from its ledger.
+func (s *Service) Rebuild(ctx context.Context, accountID int64) (*account.Account, error) {
+	acc, err := s.accounts.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := s.repo.Balance(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if acc.Balance == derived {
+		return acc, nil
+	}
+
+	acc.Balance = derived
+	acc.UpdatedAt = time.Now()
+	if err := s.accounts.Update(ctx, acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
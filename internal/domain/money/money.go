@@ -0,0 +1,219 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+// Scale is the number of fractional digits an Amount carries. It matches the
+// DECIMAL(15, 5) columns used for amounts and balances.
+const Scale = 5
+
+const unitsPerWhole = 100000
+
+// Amount is an exact fixed-point monetary value stored as an integer number of
+// 10^-Scale units, so arithmetic on balances never drifts by rounding.
+type Amount struct {
+	units int64
+}
+
+// Zero is the zero Amount.
+var Zero = Amount{}
+
+// FromUnits returns the Amount made of the given number of 10^-Scale units.
+func FromUnits(units int64) Amount {
+	return Amount{units: units}
+}
+
+// FromInt returns the Amount for a whole number of currency units.
+func FromInt(whole int64) Amount {
+	return Amount{units: whole * unitsPerWhole}
+}
+
+// Parse reads a decimal string such as "10.15" or "-0.00001". Values with more
+// than Scale fractional digits are rejected rather than truncated.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, internal.ErrInvalidAmount
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" && frac == "" || hasPoint && frac == "" {
+		return Zero, internal.ErrInvalidAmount
+	}
+	if len(frac) > Scale {
+		return Zero, internal.ErrAmountPrecision
+	}
+
+	var units int64
+	for _, digits := range []string{whole, frac + strings.Repeat("0", Scale-len(frac))} {
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return Zero, internal.ErrInvalidAmount
+			}
+			if units > (math.MaxInt64-int64(r-'0'))/10 {
+				return Zero, internal.ErrNumericOverflow
+			}
+			units = units*10 + int64(r-'0')
+		}
+	}
+
+	if negative {
+		units = -units
+	}
+	return Amount{units: units}, nil
+}
+
+// MustParse is like Parse but panics on invalid input. It is meant for
+// constants and tests.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("money: cannot parse %q: %v", s, err))
+	}
+	return a
+}
+
+// Units returns the raw number of 10^-Scale units.
+func (a Amount) Units() int64 {
+	return a.units
+}
+
+func (a Amount) Add(b Amount) Amount {
+	return Amount{units: a.units + b.units}
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{units: a.units - b.units}
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{units: -a.units}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether a is less than, equal to or
+// greater than b.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (a Amount) LessThan(b Amount) bool {
+	return a.units < b.units
+}
+
+func (a Amount) IsZero() bool {
+	return a.units == 0
+}
+
+func (a Amount) IsNegative() bool {
+	return a.units < 0
+}
+
+// String formats the amount with trailing zeros trimmed, keeping at least two
+// fractional digits ("10.15", "3.00", "0.00001").
+func (a Amount) String() string {
+	units := a.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+
+	// Work on the absolute value as uint64 so math.MinInt64 does not overflow
+	abs := uint64(units)
+	if units < 0 {
+		abs = uint64(-(units + 1)) + 1
+	}
+
+	frac := fmt.Sprintf("%0*d", Scale, abs%unitsPerWhole)
+	frac = strings.TrimRight(frac, "0")
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	return fmt.Sprintf("%s%d.%s", sign, abs/unitsPerWhole, frac)
+}
+
+// MarshalJSON encodes the amount as a JSON string to avoid float conversion
+// on the client side.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts both a JSON string ("10.15") and a bare JSON number.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return internal.ErrInvalidAmount
+		}
+	} else {
+		s = string(data)
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner so amounts can be read straight from NUMERIC
+// columns. pgx hands NUMERIC values over in their text representation.
+func (a *Amount) Scan(src interface{}) error {
+	var parsed Amount
+	var err error
+
+	switch v := src.(type) {
+	case nil:
+		*a = Zero
+		return nil
+	case string:
+		parsed, err = Parse(v)
+	case []byte:
+		parsed, err = Parse(string(v))
+	case int64:
+		parsed = FromInt(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", src)
+	}
+	if err != nil {
+		return fmt.Errorf("money: cannot scan %v into Amount: %w", src, err)
+	}
+
+	*a = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, encoding the amount as an exact decimal string.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
@@ -0,0 +1,90 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedUnits int64
+		expectedError error
+	}{
+		{input: "10.15", expectedUnits: 1015000},
+		{input: "0.00001", expectedUnits: 1},
+		{input: "-3", expectedUnits: -300000},
+		{input: "+7.5", expectedUnits: 750000},
+		{input: "9999999999.99999", expectedUnits: 999999999999999},
+		{input: "0.000001", expectedError: internal.ErrAmountPrecision},
+		{input: "1.2.3", expectedError: internal.ErrInvalidAmount},
+		{input: "abc", expectedError: internal.ErrInvalidAmount},
+		{input: "5.", expectedError: internal.ErrInvalidAmount},
+		{input: "", expectedError: internal.ErrInvalidAmount},
+		{input: "99999999999999999999", expectedError: internal.ErrNumericOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			amount, err := Parse(tc.input)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedUnits, amount.Units())
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "10.15", MustParse("10.15000").String())
+	assert.Equal(t, "3.00", FromInt(3).String())
+	assert.Equal(t, "0.00001", FromUnits(1).String())
+	assert.Equal(t, "-0.50", MustParse("-0.5").String())
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	sum := Zero
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(MustParse("0.1"))
+	}
+	assert.Equal(t, FromInt(1), sum)
+	assert.Equal(t, MustParse("0.7"), FromInt(1).Sub(MustParse("0.3")))
+	assert.True(t, MustParse("0.3").LessThan(MustParse("0.30001")))
+}
+
+func TestJSON(t *testing.T) {
+	var payload struct {
+		Amount Amount `json:"amount"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"10.15"}`), &payload))
+	assert.Equal(t, MustParse("10.15"), payload.Amount)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":2.5}`), &payload))
+	assert.Equal(t, MustParse("2.5"), payload.Amount)
+
+	err := json.Unmarshal([]byte(`{"amount":"1.123456"}`), &payload)
+	assert.ErrorIs(t, err, internal.ErrAmountPrecision)
+
+	encoded, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"2.50"}`, string(encoded))
+}
+
+func TestScan(t *testing.T) {
+	var a Amount
+	require.NoError(t, a.Scan("1015.00000"))
+	assert.Equal(t, MustParse("1015"), a)
+
+	require.NoError(t, a.Scan(nil))
+	assert.True(t, a.IsZero())
+
+	assert.Error(t, a.Scan(1.5))
+}
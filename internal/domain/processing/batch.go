@@ -0,0 +1,251 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
+)
+
+// MaxBatchSize bounds the transactions of a batch.
+const MaxBatchSize = 500
+
+// BatchMode decides what happens to a batch some of whose transactions are
+// rejected.
+type BatchMode string
+
+const (
+	// BatchAtomic processes all transactions of the batch or none.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort processes every transaction that can be processed.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+func ParseBatchMode(s string) (BatchMode, error) {
+	switch m := BatchMode(s); m {
+	case BatchAtomic, BatchBestEffort:
+		return m, nil
+	case "":
+		return BatchAtomic, nil
+	default:
+		return "", fmt.Errorf("unknown batch mode %q", s)
+	}
+}
+
+// ItemStatus is the outcome of one transaction of a batch.
+type ItemStatus string
+
+const (
+	ItemCreated ItemStatus = "created"
+	// ItemDuplicate was processed before. With the same payload it is a
+	// replay and carries the original transaction; otherwise it is rejected.
+	ItemDuplicate         ItemStatus = "duplicate"
+	ItemInsufficientFunds ItemStatus = "insufficient_funds"
+	ItemInvalid           ItemStatus = "invalid"
+	// ItemAborted could have been processed, but the atomic batch it is part
+	// of was rejected.
+	ItemAborted ItemStatus = "aborted"
+)
+
+// BatchItem reports the outcome of the transaction at the same position of
+// the batch.
+type BatchItem struct {
+	TransactionID string                   `json:"transactionId"`
+	Status        ItemStatus               `json:"status"`
+	Transaction   *transaction.Transaction `json:"transaction,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+	Diff          []transaction.FieldDiff  `json:"diff,omitempty"`
+}
+
+// BatchResult is the outcome of a batch. Committed is false when an atomic
+// batch was rejected and nothing was stored.
+type BatchResult struct {
+	Mode      BatchMode    `json:"mode"`
+	Committed bool         `json:"committed"`
+	Balance   money.Amount `json:"balance"`
+	Items     []BatchItem  `json:"results"`
+}
+
+// errBatchRejected rolls back the unit of work of a rejected atomic batch.
+var errBatchRejected = errors.New("atomic batch rejected")
+
+// ProcessBatch processes the transactions of one account and provider in a
+// single unit of work, in order, with the same rules as ProcessTransaction.
+// Rejected transactions are reported per item rather than as an error.
+func (s *Service) ProcessBatch(ctx context.Context, mode BatchMode, txs []*transaction.Transaction) (result *BatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "processing.ProcessBatch", trace.WithAttributes(
+		attribute.String("batch.mode", string(mode)),
+		attribute.Int("batch.size", len(txs)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if len(txs) == 0 {
+		return nil, internal.ErrEmptyBatch
+	}
+	if len(txs) > MaxBatchSize {
+		return nil, internal.ErrBatchTooLarge
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		span.SetAttributes(attribute.Int("attempts", attempt+1))
+		result, err = s.processBatch(ctx, mode, txs)
+		if !retryable(err) {
+			break
+		}
+	}
+	if result != nil {
+		span.SetAttributes(attribute.Bool("batch.committed", result.Committed))
+	}
+	return result, err
+}
+
+func (s *Service) processBatch(ctx context.Context, mode BatchMode, txs []*transaction.Transaction) (*BatchResult, error) {
+	result := &BatchResult{Mode: mode, Items: make([]BatchItem, len(txs))}
+
+	err := s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		// The handler hands over transactions of a single account and provider
+		accountID, sourceType := txs[0].AccountID, txs[0].SourceType
+		acc, err := repos.Accounts.GetByIDForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		result.Balance = acc.Balance
+
+		ids := make([]string, len(txs))
+		for i, tx := range txs {
+			ids[i] = tx.TransactionID
+		}
+		stored, err := repos.Transactions.GetByIDs(ctx, sourceType, ids)
+		if err != nil {
+			return err
+		}
+		originals := make(map[string]*transaction.Transaction, len(stored))
+		for _, tx := range stored {
+			originals[tx.TransactionID] = tx
+		}
+		inBatch := make(map[string]bool, len(txs))
+		rounds := newRoundBook(repos.Rounds)
+
+		now := time.Now()
+		var created []*transaction.Transaction
+		var entries []*ledger.Entry
+		failed := false
+		for i, tx := range txs {
+			item := &result.Items[i]
+			item.TransactionID = tx.TransactionID
+
+			if err := tx.Validate(); err != nil {
+				item.Status, item.Error = ItemInvalid, "invalid transaction"
+				failed = true
+				continue
+			}
+			if tx.AccountID != accountID || tx.SourceType != sourceType {
+				return internal.ErrAccountMismatch
+			}
+			if tx.State == transaction.StateRollback {
+				item.Status, item.Error = ItemInvalid, "rollbacks must be submitted on their own"
+				failed = true
+				continue
+			}
+
+			if inBatch[tx.TransactionID] {
+				item.Status, item.Error = ItemDuplicate, "repeats an earlier transaction of the batch"
+				failed = true
+				continue
+			}
+			inBatch[tx.TransactionID] = true
+
+			if original, ok := originals[tx.TransactionID]; ok {
+				item.Status = ItemDuplicate
+				if diff := original.Diff(tx); len(diff) == 0 && original.BalanceAfter != nil {
+					item.Transaction = original
+				} else {
+					item.Error, item.Diff = "duplicate transaction", diff
+					failed = true
+				}
+				continue
+			}
+
+			var joined *round.Round
+			if tx.RoundID != "" {
+				joined, err = rounds.admit(ctx, tx, now)
+				if errors.Is(err, internal.ErrRoundNotFound) || errors.Is(err, internal.ErrRoundClosed) {
+					item.Status, item.Error = ItemInvalid, err.Error()
+					failed = true
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := acc.ApplyTransaction(tx); err != nil {
+				if !errors.Is(err, internal.ErrInsufficientFunds) {
+					return err
+				}
+				item.Status, item.Error = ItemInsufficientFunds, "insufficient funds"
+				failed = true
+				continue
+			}
+			if joined != nil {
+				rounds.keep(joined)
+			}
+
+			balanceAfter := acc.Balance
+			tx.BalanceAfter = &balanceAfter
+			tx.ProcessedAt = now
+			item.Status, item.Transaction = ItemCreated, tx
+			created = append(created, tx)
+		}
+
+		if failed && mode == BatchAtomic {
+			for i := range result.Items {
+				if result.Items[i].Status == ItemCreated {
+					result.Items[i].Status, result.Items[i].Transaction = ItemAborted, nil
+				}
+			}
+			return errBatchRejected
+		}
+
+		if err := rounds.save(ctx); err != nil {
+			return err
+		}
+		if err := repos.Transactions.CreateMany(ctx, created); err != nil {
+			return err
+		}
+		for _, tx := range created {
+			entry := ledger.NewEntry(acc.ID, &tx.ID, ledger.ReasonApply, tx.BalanceChange())
+			entries = append(entries, &entry)
+		}
+		if err := repos.Ledger.AppendMany(ctx, entries); err != nil {
+			return err
+		}
+		if len(created) > 0 {
+			if err := repos.Accounts.Update(ctx, acc); err != nil {
+				return err
+			}
+		}
+
+		result.Committed = true
+		result.Balance = acc.Balance
+		return nil
+	})
+	if errors.Is(err, errBatchRejected) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+// processRollback reverses the original transaction a rollback names and
+// stores the rollback linked to it. The original is canceled the same way the
+// worker cancels transactions, so neither can reverse it a second time.
+func processRollback(ctx context.Context, repos Repositories, acc *account.Account, rollback *transaction.Transaction) error {
+	// Only the provider's own transactions on the same account can be reversed
+	original, err := repos.Transactions.GetByID(ctx, rollback.SourceType, rollback.OriginalTransactionID)
+	if errors.Is(err, internal.ErrTransactionNotFound) || (err == nil && original.AccountID != rollback.AccountID) {
+		return internal.ErrOriginalNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if original.State == transaction.StateRollback || original.Amount.Cmp(rollback.Amount) != 0 {
+		return internal.ErrRollbackMismatch
+	}
+	if original.IsCanceled {
+		return internal.ErrAlreadyReversed
+	}
+
+	// Unlike the worker, a provider's rollback never overdraws the account
+	reversed, _, err := acc.ReverseTransaction(original, transaction.OverdraftSkip)
+	if err != nil {
+		return err
+	}
+	if err := repos.Transactions.Cancel(ctx, original.ID); err != nil {
+		return err
+	}
+
+	balanceAfter := acc.Balance
+	rollback.BalanceAfter = &balanceAfter
+	rollback.Reverses = &original.ID
+	rollback.ProcessedAt = time.Now()
+	if err := repos.Transactions.Create(ctx, rollback); err != nil {
+		return err
+	}
+
+	entry := ledger.NewEntry(acc.ID, &original.ID, ledger.ReasonCancel, reversed)
+	if err := repos.Ledger.Append(ctx, &entry); err != nil {
+		return err
+	}
+
+	return repos.Accounts.Update(ctx, acc)
+}
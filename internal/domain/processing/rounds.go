@@ -0,0 +1,75 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+// roundBook tracks the rounds the transactions of one unit of work belong
+// to, so transactions of the same round processed together see each other's
+// changes. Nothing is written before save.
+type roundBook struct {
+	repo round.Repository
+	// rounds holds the loaded rounds, nil for those that do not exist yet
+	rounds  map[string]*round.Round
+	changed []string
+}
+
+func newRoundBook(repo round.Repository) *roundBook {
+	return &roundBook{repo: repo, rounds: make(map[string]*round.Round)}
+}
+
+// admit checks tx against the rules of its round and returns the round as it
+// is once tx joined it. The book only takes the change over with keep, once
+// tx was applied.
+func (b *roundBook) admit(ctx context.Context, tx *transaction.Transaction, now time.Time) (*round.Round, error) {
+	current, ok := b.rounds[tx.RoundID]
+	if !ok {
+		// The account is locked already, so the round is locked after it
+		loaded, err := b.repo.GetForUpdate(ctx, tx.SourceType, tx.RoundID)
+		if err != nil && !errors.Is(err, internal.ErrRoundNotFound) {
+			return nil, err
+		}
+		current = loaded
+		b.rounds[tx.RoundID] = current
+	}
+
+	if current == nil {
+		return round.Open(tx, now)
+	}
+	return current.Admit(tx, now)
+}
+
+// keep takes over a round returned by admit.
+func (b *roundBook) keep(r *round.Round) {
+	previous := b.rounds[r.RoundID]
+	if (previous == nil || previous.Status != r.Status) && !slices.Contains(b.changed, r.RoundID) {
+		b.changed = append(b.changed, r.RoundID)
+	}
+	b.rounds[r.RoundID] = r
+}
+
+// save writes the rounds opened or closed since the book was created. Rounds
+// must be saved before the transactions referring to them.
+func (b *roundBook) save(ctx context.Context) error {
+	for _, id := range b.changed {
+		r := b.rounds[id]
+		if r.ID == 0 {
+			if err := b.repo.Create(ctx, r); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := b.repo.Close(ctx, r.ID, *r.ClosedAt); err != nil {
+			return err
+		}
+	}
+	b.changed = nil
+	return nil
+}
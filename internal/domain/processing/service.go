@@ -0,0 +1,167 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
+)
+
+var tracer = otel.Tracer("github.com/blackcloro/transaction-processor/internal/domain/processing")
+
+// maxAttempts bounds how often a unit of work is retried after losing an
+// optimistic concurrency check on the account.
+const maxAttempts = 3
+
+type Service struct {
+	uow UnitOfWork
+}
+
+func NewService(uow UnitOfWork) *Service {
+	return &Service{uow: uow}
+}
+
+// Result is the outcome of a successfully processed transaction.
+type Result struct {
+	Transaction *transaction.Transaction
+	Balance     money.Amount
+	// Replayed is set when the transaction had already been processed with
+	// the same payload; the result is then the one of the original request.
+	Replayed bool
+}
+
+// ProcessTransaction records tx and applies it to its account's balance in a
+// single unit of work, so a transaction is never stored without its balance
+// change (or the other way around).
+//
+// A rollback reverses the transaction it names instead; see processRollback.
+// Bets and wins with a round ID follow the rules of their round: a bet opens
+// the round or joins the open one, a win needs an open round and closes it.
+//
+// Resubmitting a processed transaction is idempotent: with the same payload
+// the original result is returned, with a different one a
+// *transaction.DuplicateError listing the differences.
+func (s *Service) ProcessTransaction(ctx context.Context, tx *transaction.Transaction) (result *Result, err error) {
+	ctx, span := tracer.Start(ctx, "processing.ProcessTransaction", trace.WithAttributes(
+		attribute.Int64("account.id", tx.AccountID),
+		attribute.String("transaction.id", tx.TransactionID),
+		attribute.String("transaction.source_type", string(tx.SourceType)),
+		attribute.String("transaction.state", string(tx.State)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		span.SetAttributes(attribute.Int("attempts", attempt+1))
+		result, err = s.processTransaction(ctx, tx)
+		if !retryable(err) {
+			break
+		}
+	}
+	if result != nil && result.Replayed {
+		span.SetAttributes(attribute.Bool("transaction.replayed", true))
+	}
+	return result, err
+}
+
+// retryable reports whether a unit of work lost a race and may succeed when
+// run again: another request changed the account, or inserted the same
+// transaction ID first, which the next attempt then finds as a duplicate.
+func retryable(err error) bool {
+	var duplicate *transaction.DuplicateError
+	if errors.As(err, &duplicate) {
+		return false
+	}
+	return errors.Is(err, internal.ErrConcurrentModification) || errors.Is(err, internal.ErrDuplicateTransaction)
+}
+
+func (s *Service) processTransaction(ctx context.Context, tx *transaction.Transaction) (*Result, error) {
+	var result *Result
+	err := s.uow.Do(ctx, func(ctx context.Context, repos Repositories) error {
+		// Lock the account first so concurrent requests for it are serialized,
+		// including the duplicate check below
+		acc, err := repos.Accounts.GetByIDForUpdate(ctx, tx.AccountID)
+		if err != nil {
+			return err
+		}
+
+		original, err := repos.Transactions.GetByID(ctx, tx.SourceType, tx.TransactionID)
+		if err == nil {
+			diff := original.Diff(tx)
+			if len(diff) > 0 || original.BalanceAfter == nil {
+				return &transaction.DuplicateError{Diff: diff}
+			}
+			result = &Result{Transaction: original, Balance: *original.BalanceAfter, Replayed: true}
+			return nil
+		}
+		if !errors.Is(err, internal.ErrTransactionNotFound) {
+			return err
+		}
+
+		if tx.State == transaction.StateRollback {
+			if err := processRollback(ctx, repos, acc, tx); err != nil {
+				return err
+			}
+			result = &Result{Transaction: tx, Balance: acc.Balance}
+			return nil
+		}
+
+		now := time.Now()
+		var rounds *roundBook
+		var joined *round.Round
+		if tx.RoundID != "" {
+			rounds = newRoundBook(repos.Rounds)
+			if joined, err = rounds.admit(ctx, tx, now); err != nil {
+				return err
+			}
+		}
+
+		if err := acc.ApplyTransaction(tx); err != nil {
+			return err
+		}
+
+		if joined != nil {
+			rounds.keep(joined)
+			if err := rounds.save(ctx); err != nil {
+				return err
+			}
+		}
+
+		balanceAfter := acc.Balance
+		tx.BalanceAfter = &balanceAfter
+		tx.ProcessedAt = now
+		if err := repos.Transactions.Create(ctx, tx); err != nil {
+			return err
+		}
+
+		entry := ledger.NewEntry(acc.ID, &tx.ID, ledger.ReasonApply, tx.BalanceChange())
+		if err := repos.Ledger.Append(ctx, &entry); err != nil {
+			return err
+		}
+
+		if err := repos.Accounts.Update(ctx, acc); err != nil {
+			return err
+		}
+
+		result = &Result{Transaction: tx, Balance: acc.Balance}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
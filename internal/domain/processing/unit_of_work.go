@@ -0,0 +1,25 @@
+package processing
+
+import (
+	"context"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+// Repositories groups the repositories bound to a single unit of work. All of
+// them read and write through the same underlying database transaction.
+type Repositories struct {
+	Accounts     account.Repository
+	Transactions transaction.Repository
+	Ledger       ledger.Repository
+	Rounds       round.Repository
+}
+
+// UnitOfWork runs fn atomically: every change made through the given
+// repositories is committed when fn returns nil and rolled back otherwise.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
@@ -0,0 +1,74 @@
+package reconciliation
+
+import (
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+// AccountBalances holds the three views of an account's balance: the stored
+// column, the balance expected from its transactions and the balance derived
+// from its ledger postings.
+type AccountBalances struct {
+	AccountID int64        `json:"account_id"`
+	Stored    money.Amount `json:"stored"`
+	Expected  money.Amount `json:"expected"`
+	Ledger    money.Amount `json:"ledger"`
+}
+
+func (b AccountBalances) Consistent() bool {
+	return b.Stored == b.Expected && b.Ledger == b.Expected
+}
+
+// OffendingRow is a transaction whose ledger postings do not add up to the
+// effect it should have on the balance, e.g. a cancellation reversed twice.
+type OffendingRow struct {
+	ID            int64        `json:"id"`
+	TransactionID string       `json:"transaction_id"`
+	IsCanceled    bool         `json:"is_canceled"`
+	Expected      money.Amount `json:"expected"`
+	Posted        money.Amount `json:"posted"`
+}
+
+// Difference is the adjustment that brings the row's postings to its
+// expected effect.
+func (r OffendingRow) Difference() money.Amount {
+	return r.Expected.Sub(r.Posted)
+}
+
+type Discrepancy struct {
+	AccountBalances
+	OffendingRows []OffendingRow `json:"offending_rows"`
+}
+
+// Audit records a repair made by the reconciler.
+type Audit struct {
+	ID              int64        `json:"id"`
+	AccountID       int64        `json:"account_id"`
+	PreviousBalance money.Amount `json:"previous_balance"`
+	NewBalance      money.Amount `json:"new_balance"`
+	LedgerBalance   money.Amount `json:"ledger_balance"`
+	AdjustedRows    int          `json:"adjusted_rows"`
+	Note            string       `json:"note"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type Options struct {
+	// AccountID restricts the run to one account; zero checks every account.
+	AccountID int64
+	// Repair brings inconsistent accounts back in line with their transactions.
+	Repair bool
+	// Note is stored on the audit record of every repair.
+	Note string
+}
+
+type Report struct {
+	Checked       int           `json:"checked"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Repairs       []Audit       `json:"repairs"`
+}
+
+// Clean reports whether no inconsistency is left after the run.
+func (r *Report) Clean() bool {
+	return len(r.Discrepancies) == len(r.Repairs)
+}
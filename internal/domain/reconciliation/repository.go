@@ -0,0 +1,15 @@
+package reconciliation
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// ListBalances returns the balances of one account, or of every account
+	// when accountID is zero.
+	ListBalances(ctx context.Context, accountID int64) ([]AccountBalances, error)
+	ListOffendingRows(ctx context.Context, accountID int64) ([]OffendingRow, error)
+	// Repair atomically books an adjustment posting for every offending row,
+	// sets the stored balance to the expected one and records an audit.
+	Repair(ctx context.Context, accountID int64, note string) (*Audit, error)
+}
@@ -0,0 +1,49 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+)
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Run compares every account's stored balance with the balance expected from
+// its non-canceled transactions and with its ledger, and optionally repairs
+// the accounts that disagree.
+func (s *Service) Run(ctx context.Context, opts Options) (*Report, error) {
+	balances, err := s.repo.ListBalances(ctx, opts.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Checked: len(balances), Discrepancies: []Discrepancy{}, Repairs: []Audit{}}
+	for _, b := range balances {
+		if b.Consistent() {
+			continue
+		}
+
+		rows, err := s.repo.ListOffendingRows(ctx, b.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("listing offending rows of account %d: %w", b.AccountID, err)
+		}
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{AccountBalances: b, OffendingRows: rows})
+
+		if !opts.Repair {
+			continue
+		}
+
+		audit, err := s.repo.Repair(ctx, b.AccountID, opts.Note)
+		if err != nil {
+			return nil, fmt.Errorf("repairing account %d: %w", b.AccountID, err)
+		}
+		report.Repairs = append(report.Repairs, *audit)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,24 @@
+package round
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+type Repository interface {
+	// Create stores the round and sets its id.
+	Create(ctx context.Context, round *Round) error
+	// Get returns the round the provider identified by sourceType opened as
+	// roundID, or internal.ErrRoundNotFound.
+	Get(ctx context.Context, sourceType transaction.SourceType, roundID string) (*Round, error)
+	// GetForUpdate is Get, locking the round until the surrounding unit of
+	// work ends.
+	GetForUpdate(ctx context.Context, sourceType transaction.SourceType, roundID string) (*Round, error)
+	// Close closes the round with the given id at closedAt.
+	Close(ctx context.Context, id int64, closedAt time.Time) error
+	// CloseOpenedBefore closes the rounds still open that were opened before
+	// cutoff at closedAt, and returns how many it closed.
+	CloseOpenedBefore(ctx context.Context, cutoff, closedAt time.Time) (int64, error)
+}
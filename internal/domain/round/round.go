@@ -0,0 +1,60 @@
+package round
+
+import (
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+type Status string
+
+const (
+	StatusOpen   Status = "open"
+	StatusClosed Status = "closed"
+)
+
+// Round groups the transactions of one game round of an account: the bets
+// debited when it is played and the win credited when it is settled. Round
+// IDs are assigned by the providers, so they are only unique per provider.
+type Round struct {
+	ID         int64                  `json:"id"`
+	RoundID    string                 `json:"roundId"`
+	AccountID  int64                  `json:"account_id"`
+	SourceType transaction.SourceType `json:"source_type"`
+	Status     Status                 `json:"status"`
+	OpenedAt   time.Time              `json:"opened_at"`
+	ClosedAt   *time.Time             `json:"closed_at,omitempty"`
+}
+
+// Open starts the round of tx. Only bets open rounds; a win must belong to
+// a round opened before, so it yields internal.ErrRoundNotFound.
+func Open(tx *transaction.Transaction, now time.Time) (*Round, error) {
+	if tx.State != transaction.StateLost {
+		return nil, internal.ErrRoundNotFound
+	}
+	return &Round{
+		RoundID:    tx.RoundID,
+		AccountID:  tx.AccountID,
+		SourceType: tx.SourceType,
+		Status:     StatusOpen,
+		OpenedAt:   now,
+	}, nil
+}
+
+// Admit returns the round as it is once tx joined it, leaving r untouched.
+// Closed rounds take no more transactions; a win settles the round and
+// closes it.
+func (r Round) Admit(tx *transaction.Transaction, now time.Time) (*Round, error) {
+	if r.AccountID != tx.AccountID {
+		return nil, internal.ErrRoundNotFound
+	}
+	if r.Status == StatusClosed {
+		return nil, internal.ErrRoundClosed
+	}
+	if tx.State == transaction.StateWin {
+		r.Status = StatusClosed
+		r.ClosedAt = &now
+	}
+	return &r, nil
+}
@@ -0,0 +1,45 @@
+package round
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+func TestRoundRules(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tx := func(state transaction.State, accountID int64) *transaction.Transaction {
+		return &transaction.Transaction{AccountID: accountID, SourceType: transaction.SourceTypeGame, State: state, Amount: money.FromInt(1), RoundID: "r1"}
+	}
+
+	_, err := Open(tx(transaction.StateWin, 1), now)
+	assert.ErrorIs(t, err, internal.ErrRoundNotFound, "a win cannot open a round")
+
+	open, err := Open(tx(transaction.StateLost, 1), now)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, open.Status)
+
+	joined, err := open.Admit(tx(transaction.StateLost, 1), now)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOpen, joined.Status)
+
+	_, err = open.Admit(tx(transaction.StateLost, 2), now)
+	assert.ErrorIs(t, err, internal.ErrRoundNotFound, "another account's round")
+
+	settled, err := open.Admit(tx(transaction.StateWin, 1), now)
+	require.NoError(t, err)
+	assert.Equal(t, StatusClosed, settled.Status)
+	assert.Equal(t, &now, settled.ClosedAt)
+	assert.Equal(t, StatusOpen, open.Status, "Admit leaves the round untouched")
+
+	for _, state := range []transaction.State{transaction.StateLost, transaction.StateWin} {
+		_, err = settled.Admit(tx(state, 1), now)
+		assert.ErrorIs(t, err, internal.ErrRoundClosed)
+	}
+}
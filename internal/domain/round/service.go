@@ -0,0 +1,62 @@
+package round
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
+)
+
+var tracer = otel.Tracer("github.com/blackcloro/transaction-processor/internal/domain/round")
+
+type Service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetRound returns the round the provider identified by sourceType opened as
+// roundID, provided it belongs to the account.
+func (s *Service) GetRound(ctx context.Context, accountID int64, sourceType transaction.SourceType, roundID string) (_ *Round, err error) {
+	ctx, span := tracer.Start(ctx, "round.GetRound", trace.WithAttributes(
+		attribute.Int64("account.id", accountID),
+		attribute.String("round.id", roundID),
+		attribute.String("transaction.source_type", string(sourceType)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	round, err := s.repo.Get(ctx, sourceType, roundID)
+	if err != nil {
+		return nil, err
+	}
+	if round.AccountID != accountID {
+		return nil, internal.ErrRoundNotFound
+	}
+	return round, nil
+}
+
+// CloseStale closes the rounds that have been open for longer than timeout,
+// e.g. lost rounds whose provider never sends a win, and returns how many it
+// closed.
+func (s *Service) CloseStale(ctx context.Context, timeout time.Duration) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "round.CloseStale", trace.WithAttributes(
+		attribute.String("round.timeout", timeout.String()),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	closed, err := s.repo.CloseOpenedBefore(ctx, now.Add(-timeout), now)
+	if err != nil {
+		return 0, err
+	}
+	span.SetAttributes(attribute.Int64("rounds.closed", closed))
+	return closed, nil
+}
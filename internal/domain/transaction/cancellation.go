@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+// OverdraftPolicy decides what happens when reversing a canceled win would
+// take the account balance below zero.
+type OverdraftPolicy string
+
+const (
+	// OverdraftSkip leaves the transaction active and reports it as skipped.
+	OverdraftSkip OverdraftPolicy = "skip"
+	// OverdraftPartial cancels the transaction but only reverses what the
+	// balance covers; the rest is written off.
+	OverdraftPartial OverdraftPolicy = "partial"
+	// OverdraftClamp cancels the transaction, clamps the balance to zero and
+	// records the unreversed rest as a debt of the account.
+	OverdraftClamp OverdraftPolicy = "clamp"
+)
+
+func ParseOverdraftPolicy(s string) (OverdraftPolicy, error) {
+	switch p := OverdraftPolicy(s); p {
+	case OverdraftSkip, OverdraftPartial, OverdraftClamp:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown overdraft policy %q", s)
+	}
+}
+
+// CanceledTransaction describes one transaction flipped to canceled.
+type CanceledTransaction struct {
+	ID            int64      `json:"id"`
+	TransactionID string     `json:"transaction_id"`
+	SourceType    SourceType `json:"source_type"`
+	// Reversed is the signed change applied to the balance by the cancellation.
+	Reversed money.Amount `json:"reversed"`
+	// Shortfall is the part of the reversal the balance could not cover.
+	Shortfall money.Amount `json:"shortfall"`
+}
+
+// CancellationResult reports what a cancellation run did with each
+// requested transaction of an account. Transactions are referred to by their
+// internal ids.
+type CancellationResult struct {
+	AccountID       int64                 `json:"account_id"`
+	Policy          OverdraftPolicy       `json:"policy"`
+	Canceled        []CanceledTransaction `json:"canceled"`
+	AlreadyCanceled []int64               `json:"already_canceled"`
+	// Skipped transactions would have overdrawn the account and were left active.
+	Skipped []int64 `json:"skipped"`
+	// NotFound transactions do not exist on the account, or are rollbacks.
+	NotFound []int64 `json:"not_found"`
+	// Debt is the total shortfall recorded as debt under OverdraftClamp.
+	Debt money.Amount `json:"debt"`
+}
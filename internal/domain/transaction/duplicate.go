@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+// FieldDiff is a field whose value in a resubmitted transaction differs from
+// the stored one.
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Stored   string `json:"stored"`
+	Received string `json:"received"`
+}
+
+// Diff compares the payload of a resubmitted transaction with the stored
+// one: the fields a provider sends, not those the service assigns. Both come
+// from the same provider, so their source types always match.
+func (t *Transaction) Diff(received *Transaction) []FieldDiff {
+	var diff []FieldDiff
+	add := func(field, stored, received string) {
+		if stored != received {
+			diff = append(diff, FieldDiff{Field: field, Stored: stored, Received: received})
+		}
+	}
+	add("account_id", strconv.FormatInt(t.AccountID, 10), strconv.FormatInt(received.AccountID, 10))
+	add("state", string(t.State), string(received.State))
+	add("original_transaction_id", t.OriginalTransactionID, received.OriginalTransactionID)
+	add("round_id", t.RoundID, received.RoundID)
+	if t.Amount.Cmp(received.Amount) != 0 {
+		diff = append(diff, FieldDiff{Field: "amount", Stored: t.Amount.String(), Received: received.Amount.String()})
+	}
+	return diff
+}
+
+// DuplicateError reports a transaction ID that was already processed and
+// cannot be replayed: its payload differs, or it predates stored results.
+type DuplicateError struct {
+	Diff []FieldDiff
+}
+
+func (e *DuplicateError) Error() string {
+	if len(e.Diff) == 0 {
+		return internal.ErrDuplicateTransaction.Error()
+	}
+	return fmt.Sprintf("%s with a different payload (%d fields differ)", internal.ErrDuplicateTransaction, len(e.Diff))
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return internal.ErrDuplicateTransaction
+}
@@ -0,0 +1,79 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Filter narrows down a transaction listing of one account. Nil fields are
+// not applied. Results are ordered by (ProcessedAt, ID), newest first.
+type Filter struct {
+	AccountID  int64
+	SourceType *SourceType
+	State      *State
+	IsCanceled *bool
+	RoundID    *string
+	MinAmount  *money.Amount
+	MaxAmount  *money.Amount
+	// ExcludeRollbacks leaves out rollbacks, which cannot be canceled.
+	ExcludeRollbacks bool
+	// From and To bound processed_at as the half-open interval [From, To).
+	From  *time.Time
+	To    *time.Time
+	After *Cursor
+	Limit int
+}
+
+// Cursor is a keyset pagination position: the listing continues with rows
+// strictly older than (ProcessedAt, ID).
+type Cursor struct {
+	ProcessedAt time.Time
+	ID          int64
+}
+
+// Page is one page of a transaction listing. NextCursor is nil on the last page.
+type Page struct {
+	Transactions []*Transaction
+	NextCursor   *Cursor
+}
+
+// Encode returns an opaque, URL-safe representation of the cursor.
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.ProcessedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor previously produced by Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, internal.ErrInvalidCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return Cursor{}, internal.ErrInvalidCursor
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, internal.ErrInvalidCursor
+	}
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return Cursor{}, internal.ErrInvalidCursor
+	}
+
+	return Cursor{ProcessedAt: time.Unix(0, unixNano), ID: rowID}, nil
+}
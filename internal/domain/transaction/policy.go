@@ -0,0 +1,94 @@
+package transaction
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+// DefaultBatchSize is the number of candidates examined per account and run.
+const DefaultBatchSize = 20
+
+// PostProcessingPolicy decides which transactions the worker cancels.
+type PostProcessingPolicy interface {
+	Name() string
+	// Select returns the candidates to cancel. Candidates are the account's
+	// active transactions other than rollbacks matching the selection
+	// criteria, newest first.
+	Select(candidates []*Transaction) []*Transaction
+}
+
+// OddRowsPolicy cancels every other candidate, starting with the newest.
+type OddRowsPolicy struct{}
+
+func (OddRowsPolicy) Name() string { return "odd-rows" }
+
+func (OddRowsPolicy) Select(candidates []*Transaction) []*Transaction {
+	selected := make([]*Transaction, 0, (len(candidates)+1)/2)
+	for i := 0; i < len(candidates); i += 2 {
+		selected = append(selected, candidates[i])
+	}
+	return selected
+}
+
+// CancelAllPolicy cancels every candidate.
+type CancelAllPolicy struct{}
+
+func (CancelAllPolicy) Name() string { return "all" }
+
+func (CancelAllPolicy) Select(candidates []*Transaction) []*Transaction {
+	return candidates
+}
+
+func ParsePostProcessingPolicy(name string) (PostProcessingPolicy, error) {
+	switch name {
+	case OddRowsPolicy{}.Name():
+		return OddRowsPolicy{}, nil
+	case CancelAllPolicy{}.Name():
+		return CancelAllPolicy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown post-processing policy %q", name)
+	}
+}
+
+// Selection narrows the candidates handed to the post-processing policy.
+// Zero values do not restrict the selection.
+type Selection struct {
+	// BatchSize caps the number of candidates per account.
+	BatchSize  int
+	SourceType *SourceType
+	// MinAge only considers transactions processed at least this long ago.
+	MinAge    time.Duration
+	MinAmount *money.Amount
+}
+
+// filter returns the listing filter for the account's candidates at now.
+func (s Selection) filter(accountID int64, now time.Time) Filter {
+	canceled := false
+	filter := Filter{
+		AccountID:        accountID,
+		SourceType:       s.SourceType,
+		IsCanceled:       &canceled,
+		ExcludeRollbacks: true,
+		MinAmount:        s.MinAmount,
+		Limit:            s.BatchSize,
+	}
+	if s.MinAge > 0 {
+		to := now.Add(-s.MinAge)
+		filter.To = &to
+	}
+	return filter
+}
+
+// PostProcessResult reports a post-processing run for one account.
+type PostProcessResult struct {
+	AccountID int64  `json:"account_id"`
+	Policy    string `json:"policy"`
+	DryRun    bool   `json:"dry_run"`
+	// Selected are the internal ids of the transactions the policy picked for
+	// cancellation.
+	Selected []int64 `json:"selected"`
+	// Cancellation is nil in dry-run mode, where nothing is canceled.
+	Cancellation *CancellationResult `json:"cancellation,omitempty"`
+}
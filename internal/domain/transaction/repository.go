@@ -6,7 +6,24 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, tx *Transaction) error
-	GetByID(ctx context.Context, id string) (*Transaction, error)
-	GetLatestOddRecords(ctx context.Context, limit int) ([]*Transaction, error)
-	MarkAsCanceled(ctx context.Context, ids []string) error
+	// CreateMany stores the transactions in one statement and sets their ids.
+	// It fails as a whole, e.g. with internal.ErrDuplicateTransaction.
+	CreateMany(ctx context.Context, txs []*Transaction) error
+	// GetByID returns the transaction the provider identified by sourceType
+	// submitted as id; transaction IDs are only unique per provider.
+	GetByID(ctx context.Context, sourceType SourceType, id string) (*Transaction, error)
+	// GetByIDs returns those of the provider's transactions with the given ids
+	// that exist, in no particular order.
+	GetByIDs(ctx context.Context, sourceType SourceType, ids []string) ([]*Transaction, error)
+	List(ctx context.Context, filter Filter) ([]*Transaction, error)
+	ListAccountIDs(ctx context.Context) ([]int64, error)
+	// Cancel flags the transaction with the given internal id as canceled
+	// without touching the balance, for callers that reverse it themselves.
+	// It fails with internal.ErrAlreadyReversed if it was canceled before.
+	Cancel(ctx context.Context, id int64) error
+	// MarkAsCanceled cancels the account's transactions with the given
+	// internal ids and reverses them on the balance. Only transactions that
+	// were not canceled yet are reversed; reversals that would overdraw follow
+	// policy. Rollbacks cannot be canceled.
+	MarkAsCanceled(ctx context.Context, accountID int64, ids []int64, policy OverdraftPolicy) (*CancellationResult, error)
 }
@@ -2,35 +2,158 @@ package transaction
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/tracing"
 )
 
+var tracer = otel.Tracer("github.com/blackcloro/transaction-processor/internal/domain/transaction")
+
+// PostProcessConfig tunes the worker's post-processing.
+type PostProcessConfig struct {
+	Policy          PostProcessingPolicy
+	Selection       Selection
+	OverdraftPolicy OverdraftPolicy
+	// DryRun reports what would be canceled without touching any data.
+	DryRun bool
+}
+
 type Service struct {
-	repo Repository
+	repo   Repository
+	config PostProcessConfig
 }
 
-func NewService(repo Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo Repository, config PostProcessConfig) *Service {
+	if config.Policy == nil {
+		config.Policy = OddRowsPolicy{}
+	}
+	if config.Selection.BatchSize <= 0 {
+		config.Selection.BatchSize = DefaultBatchSize
+	}
+	if config.OverdraftPolicy == "" {
+		config.OverdraftPolicy = OverdraftSkip
+	}
+	return &Service{repo: repo, config: config}
 }
 
-func (s *Service) CreateTransaction(ctx context.Context, tx *Transaction) error {
-	if err := tx.Validate(); err != nil {
-		return err
+// GetTransaction returns the transaction the provider identified by
+// sourceType submitted as transactionID, provided it belongs to the account.
+func (s *Service) GetTransaction(ctx context.Context, accountID int64, sourceType SourceType, transactionID string) (_ *Transaction, err error) {
+	ctx, span := tracer.Start(ctx, "transaction.GetTransaction", trace.WithAttributes(
+		attribute.Int64("account.id", accountID),
+		attribute.String("transaction.id", transactionID),
+		attribute.String("transaction.source_type", string(sourceType)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.repo.GetByID(ctx, sourceType, transactionID)
+	if err != nil {
+		return nil, err
 	}
-	tx.ProcessedAt = time.Now()
-	return s.repo.Create(ctx, tx)
+	if tx.AccountID != accountID {
+		return nil, internal.ErrTransactionNotFound
+	}
+	return tx, nil
 }
 
-func (s *Service) PostProcess(ctx context.Context) error {
-	transactions, err := s.repo.GetLatestOddRecords(ctx, 10)
+// ListTransactions returns one page of the account's transactions matching
+// filter, newest first.
+func (s *Service) ListTransactions(ctx context.Context, filter Filter) (_ *Page, err error) {
+	ctx, span := tracer.Start(ctx, "transaction.ListTransactions", trace.WithAttributes(
+		attribute.Int64("account.id", filter.AccountID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := s.repo.List(ctx, filter)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ids := make([]string, len(transactions))
-	for i, tx := range transactions {
-		ids[i] = tx.TransactionID
+	page := &Page{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = &Cursor{ProcessedAt: last.ProcessedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// PostProcess runs the post-processing policy for every account that has
+// transactions, so cancellations never mix records of different accounts. It
+// returns what was selected and, unless in dry-run mode, what was canceled,
+// skipped or left as debt for each account.
+func (s *Service) PostProcess(ctx context.Context) (_ []*PostProcessResult, err error) {
+	ctx, span := tracer.Start(ctx, "transaction.PostProcess", trace.WithAttributes(
+		attribute.String("policy", s.config.Policy.Name()),
+		attribute.Bool("dry_run", s.config.DryRun),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	accountIDs, err := s.repo.ListAccountIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]*PostProcessResult, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		result, err := s.postProcessAccount(ctx, accountID, now)
+		if err != nil {
+			return results, fmt.Errorf("post-processing account %d: %w", accountID, err)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (s *Service) postProcessAccount(ctx context.Context, accountID int64, now time.Time) (_ *PostProcessResult, err error) {
+	ctx, span := tracer.Start(ctx, "transaction.postProcessAccount", trace.WithAttributes(
+		attribute.Int64("account.id", accountID),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	candidates, err := s.repo.List(ctx, s.config.Selection.filter(accountID, now))
+	if err != nil {
+		return nil, err
+	}
+
+	selected := s.config.Policy.Select(candidates)
+	result := &PostProcessResult{
+		AccountID: accountID,
+		Policy:    s.config.Policy.Name(),
+		DryRun:    s.config.DryRun,
+		Selected:  make([]int64, len(selected)),
+	}
+	for i, tx := range selected {
+		result.Selected[i] = tx.ID
+	}
+
+	if s.config.DryRun || len(selected) == 0 {
+		return result, nil
+	}
+
+	result.Cancellation, err = s.repo.MarkAsCanceled(ctx, accountID, result.Selected, s.config.OverdraftPolicy)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.MarkAsCanceled(ctx, ids)
+	return result, nil
 }
@@ -1,9 +1,12 @@
 package transaction
 
 import (
+	"reflect"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
 )
 
 type State string
@@ -11,6 +14,9 @@ type State string
 const (
 	StateWin  State = "win"
 	StateLost State = "lost"
+	// StateRollback reverses an earlier transaction of the same account and
+	// provider, named by OriginalTransactionID.
+	StateRollback State = "rollback"
 )
 
 type SourceType string
@@ -22,17 +28,47 @@ const (
 )
 
 type Transaction struct {
-	ID            int64      `json:"id"`
-	AccountID     int64      `json:"account_id"`
-	TransactionID string     `json:"transactionId" validate:"required"`
-	SourceType    SourceType `json:"source_type" validate:"required,oneof=game server payment"`
-	State         State      `json:"state" validate:"required,oneof=win lost"`
-	Amount        float64    `json:"amount,string" validate:"required,gt=0"`
-	IsCanceled    bool       `json:"is_canceled"`
-	ProcessedAt   time.Time  `json:"processed_at"`
+	ID            int64        `json:"id"`
+	AccountID     int64        `json:"account_id"`
+	TransactionID string       `json:"transactionId" validate:"required"`
+	SourceType    SourceType   `json:"source_type" validate:"required,oneof=game server payment"`
+	State         State        `json:"state" validate:"required,oneof=win lost rollback"`
+	Amount        money.Amount `json:"amount" validate:"required,gt=0"`
+	// OriginalTransactionID is the transactionId of the transaction a
+	// rollback reverses; only rollbacks carry one.
+	OriginalTransactionID string `json:"originalTransactionId,omitempty" validate:"required_if=State rollback,excluded_unless=State rollback"`
+	// RoundID names the game round a bet or win belongs to, if any.
+	RoundID     string    `json:"roundId,omitempty" validate:"excluded_if=State rollback,max=255"`
+	IsCanceled  bool      `json:"is_canceled"`
+	ProcessedAt time.Time `json:"processed_at"`
+	// BalanceAfter is the account balance right after the transaction was
+	// applied; unknown for transactions processed before it was recorded.
+	BalanceAfter *money.Amount `json:"balance_after,omitempty"`
+	// Reverses is the internal id of the transaction a rollback reversed,
+	// ReversedBy the one of the rollback that reversed this transaction.
+	Reverses   *int64 `json:"reverses,omitempty"`
+	ReversedBy *int64 `json:"reversed_by,omitempty"`
+}
+
+// BalanceChange returns the signed effect of the transaction on its account's
+// balance: wins add the amount, losses subtract it. Rollbacks have no effect
+// of their own; the reversal is booked as the cancellation of the original.
+func (t *Transaction) BalanceChange() money.Amount {
+	switch t.State {
+	case StateLost:
+		return t.Amount.Neg()
+	case StateRollback:
+		return money.Zero
+	default:
+		return t.Amount
+	}
 }
 
 func (t *Transaction) Validate() error {
 	validate := validator.New()
+	// Validate amounts by their exact unit count so "required,gt=0" keeps working
+	validate.RegisterCustomTypeFunc(func(v reflect.Value) interface{} {
+		return v.Interface().(money.Amount).Units()
+	}, money.Amount{})
 	return validate.Struct(t)
 }
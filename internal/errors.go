@@ -7,5 +7,30 @@ var (
 	ErrDuplicateTransaction    = errors.New("duplicate transaction")
 	ErrInvalidTransactionState = errors.New("invalid transaction state")
 	ErrNumericOverflow         = errors.New("numeric field overflow")
+	ErrInvalidAmount           = errors.New("invalid amount")
+	ErrAmountPrecision         = errors.New("amount has too many fractional digits")
 	ErrTransactionNotFound     = errors.New("transaction not found")
+	ErrOriginalNotFound        = errors.New("original transaction not found")
+	ErrAlreadyReversed         = errors.New("transaction was already reversed")
+	ErrRollbackMismatch        = errors.New("rollback does not match the original transaction")
+	ErrRoundNotFound           = errors.New("round not found")
+	ErrRoundClosed             = errors.New("round is closed")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrAccountMismatch         = errors.New("transaction does not belong to account")
+	ErrConcurrentModification  = errors.New("account was modified concurrently")
+	ErrInvalidCursor           = errors.New("invalid pagination cursor")
+	ErrInvalidPeriod           = errors.New("period start must be before its end")
+	ErrUnbalancedEntry         = errors.New("ledger entry debits and credits do not balance")
+	ErrMissingSignature        = errors.New("request signature is missing")
+	ErrMissingTimestamp        = errors.New("request timestamp is missing")
+	ErrInvalidTimestamp        = errors.New("request timestamp is not a unix time")
+	ErrStaleTimestamp          = errors.New("request timestamp is outside the allowed window")
+	ErrUnknownProvider         = errors.New("no signing secret for provider")
+	ErrInvalidSignature        = errors.New("request signature does not match")
+	ErrReplayedRequest         = errors.New("request was already received")
+	ErrEmptyBatch              = errors.New("batch has no transactions")
+	ErrBatchTooLarge           = errors.New("batch has too many transactions")
+	ErrMissingAPIKey           = errors.New("api key is missing")
+	ErrInvalidAPIKey           = errors.New("api key is invalid or revoked")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
 )
@@ -6,8 +6,13 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/blackcloro/transaction-processor/pkg/logger"
 )
 
+// maxConnectBackoff caps the wait between two connection attempts.
+const maxConnectBackoff = 30 * time.Second
+
 func NewPostgresDB(dsn string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
@@ -37,3 +42,33 @@ func NewPostgresDB(dsn string) (*pgxpool.Pool, error) {
 
 	return db, nil
 }
+
+// ConnectPostgresDB connects like NewPostgresDB but keeps trying up to
+// attempts times, doubling the wait between attempts starting at backoff, so
+// the service can start before the database is reachable.
+func ConnectPostgresDB(ctx context.Context, dsn string, attempts int, backoff time.Duration) (*pgxpool.Pool, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var db *pgxpool.Pool
+		db, err = NewPostgresDB(dsn)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= attempts {
+			break
+		}
+
+		logger.Warn("Failed to connect to database, retrying",
+			"attempt", attempt,
+			"retry_in", backoff.String(),
+			"error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
@@ -2,31 +2,145 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v4"
+
+	"github.com/blackcloro/transaction-processor/internal"
 	"github.com/blackcloro/transaction-processor/internal/domain/account"
-	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type PostgresAccountRepository struct {
-	db *pgxpool.Pool
+	db Querier
 }
 
-func NewPostgresAccountRepository(db *pgxpool.Pool) *PostgresAccountRepository {
+func NewPostgresAccountRepository(db Querier) *PostgresAccountRepository {
 	return &PostgresAccountRepository{db: db}
 }
 
+func (r *PostgresAccountRepository) Create(ctx context.Context, a *account.Account) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO account (balance, version)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`, a.Balance, a.Version).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
+
 func (r *PostgresAccountRepository) GetByID(ctx context.Context, id int64) (*account.Account, error) {
+	return r.get(ctx, "SELECT id, balance, version, created_at, updated_at FROM account WHERE id = $1", id)
+}
+
+func (r *PostgresAccountRepository) List(ctx context.Context) ([]*account.Account, error) {
+	rows, err := r.db.Query(ctx, "SELECT id, balance, version, created_at, updated_at FROM account ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*account.Account
+	for rows.Next() {
+		a := &account.Account{}
+		if err := rows.Scan(&a.ID, &a.Balance, &a.Version, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *PostgresAccountRepository) GetByIDForUpdate(ctx context.Context, id int64) (*account.Account, error) {
+	return r.get(ctx, "SELECT id, balance, version, created_at, updated_at FROM account WHERE id = $1 FOR UPDATE", id)
+}
+
+func (r *PostgresAccountRepository) get(ctx context.Context, query string, id int64) (*account.Account, error) {
 	var a account.Account
-	err := r.db.QueryRow(ctx, "SELECT id, balance, version, updated_at FROM account WHERE id = $1", id).
-		Scan(&a.ID, &a.Balance, &a.Version, &a.UpdatedAt)
+	err := r.db.QueryRow(ctx, query, id).
+		Scan(&a.ID, &a.Balance, &a.Version, &a.CreatedAt, &a.UpdatedAt)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, internal.ErrAccountNotFound
+		}
 		return nil, err
 	}
 	return &a, nil
 }
 
 func (r *PostgresAccountRepository) Update(ctx context.Context, a *account.Account) error {
-	_, err := r.db.Exec(ctx, "UPDATE account SET balance = $1, version = $2, updated_at = $3 WHERE id = $4",
-		a.Balance, a.Version, a.UpdatedAt, a.ID)
-	return err
+	err := r.db.QueryRow(ctx, `
+		UPDATE account
+		SET balance = $1, version = version + 1, updated_at = $2
+		WHERE id = $3 AND version = $4
+		RETURNING version
+	`, a.Balance, a.UpdatedAt, a.ID, a.Version).Scan(&a.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return internal.ErrConcurrentModification
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresAccountRepository) GetWithMovements(ctx context.Context, id int64, since time.Time) (*account.Account, []account.Movement, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Nothing is written
+
+	// Transactions applied meanwhile must show in both reads or in neither
+	if _, err := tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY"); err != nil {
+		return nil, nil, err
+	}
+
+	repo := NewPostgresAccountRepository(tx)
+	acc, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	movements, err := repo.listMovements(ctx, id, since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return acc, movements, nil
+}
+
+func (r *PostgresAccountRepository) listMovements(ctx context.Context, id int64, since time.Time) ([]account.Movement, error) {
+	// Movements are the account's player-book postings, enriched with the
+	// transaction that caused them
+	rows, err := r.db.Query(ctx, `
+		SELECT COALESCE(t.transaction_id, ''),
+		       COALESCE(t.source_type, ''),
+		       COALESCE(t.state, ''),
+		       COALESCE(t.amount, p.amount),
+		       CASE p.reason WHEN 'apply' THEN 'applied' WHEN 'cancel' THEN 'canceled' ELSE p.reason END,
+		       CASE p.direction WHEN 'credit' THEN p.amount ELSE -p.amount END,
+		       p.created_at
+		FROM postings p
+		LEFT JOIN transactions t ON t.id = p.transaction_ref
+		WHERE p.account_id = $1 AND p.book = 'player' AND p.created_at >= $2
+		ORDER BY p.created_at, p.id
+	`, id, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []account.Movement
+	for rows.Next() {
+		var m account.Movement
+		if err := rows.Scan(&m.TransactionID, &m.SourceType, &m.State, &m.Amount, &m.Kind, &m.Change, &m.OccurredAt); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
 }
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// PostgresAdvisoryLock elects a leader among the instances sharing a database
+// with a session-level advisory lock. The lock is held on a dedicated pool
+// connection for as long as the job runs and is released with it, so a
+// crashed instance never keeps the leadership.
+type PostgresAdvisoryLock struct {
+	pool      *pgxpool.Pool
+	key       int64
+	instance  string
+	heartbeat time.Duration
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPostgresAdvisoryLock returns a lock on key. instance identifies this
+// process to the other instances; heartbeat is how often the held connection
+// is checked for a lost session.
+func NewPostgresAdvisoryLock(pool *pgxpool.Pool, key int64, instance string, heartbeat time.Duration) *PostgresAdvisoryLock {
+	return &PostgresAdvisoryLock{
+		pool:      pool,
+		key:       key,
+		instance:  instance,
+		heartbeat: heartbeat,
+	}
+}
+
+// TryLock takes the lock without waiting. The returned channel is closed when
+// the session holding the lock goes away before Unlock.
+func (l *PostgresAdvisoryLock) TryLock(ctx context.Context) (<-chan struct{}, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		return nil, false, errors.New("advisory lock is already held")
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// Name the session so other instances can tell who holds the lock
+	if _, err := conn.Exec(ctx, "SELECT set_config('application_name', $1, false)", l.instance); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to name session: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	lost := make(chan struct{})
+	l.conn = conn
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	go l.watch(conn, lost)
+
+	return lost, true, nil
+}
+
+// watch pings the connection holding the lock and closes lost once the
+// session is gone, since the lock went with it.
+func (l *PostgresAdvisoryLock) watch(conn *pgxpool.Conn, lost chan struct{}) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.heartbeat)
+			err := conn.Conn().Ping(ctx)
+			cancel()
+			if err != nil {
+				close(lost)
+				return
+			}
+		}
+	}
+}
+
+// Unlock releases the lock and returns its connection to the pool.
+func (l *PostgresAdvisoryLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	close(l.stop)
+	<-l.done
+
+	conn := l.conn
+	l.conn = nil
+
+	var unlocked bool
+	err := conn.QueryRow(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&unlocked)
+	if err == nil {
+		_, err = conn.Exec(ctx, "RESET application_name")
+	}
+	if err != nil {
+		// The session may still hold the lock, so it must not go back to the pool
+		_ = conn.Conn().Close(ctx)
+		conn.Release()
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	conn.Release()
+
+	if !unlocked {
+		return errors.New("advisory lock was not held")
+	}
+	return nil
+}
+
+// Holder returns the name of the instance holding the lock, or an empty
+// string when nobody holds it.
+func (l *PostgresAdvisoryLock) Holder(ctx context.Context) (string, error) {
+	var holder string
+	err := l.pool.QueryRow(ctx, `
+		SELECT a.application_name
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory'
+			AND l.granted
+			AND l.classid::bigint = $1
+			AND l.objid::bigint = $2
+			AND l.objsubid = 1
+	`, int64(uint32(l.key>>32)), int64(uint32(l.key))).Scan(&holder)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up lock holder: %w", err)
+	}
+	return holder, nil
+}
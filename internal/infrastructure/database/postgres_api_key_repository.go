@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+const apiKeyColumns = "id, name, prefix, source_types, account_ids, created_at, revoked_at"
+
+type PostgresAPIKeyRepository struct {
+	db Querier
+}
+
+func NewPostgresAPIKeyRepository(db Querier) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, k *apikey.APIKey, hash []byte) error {
+	accountIDs := k.AccountIDs
+	if accountIDs == nil {
+		accountIDs = []int64{}
+	}
+	return r.db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, source_types, account_ids)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, k.Name, k.Prefix, hash, sourceTypeStrings(k.SourceTypes), accountIDs).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *PostgresAPIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*apikey.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL", hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrInvalidAPIKey
+	}
+	return k, err
+}
+
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]*apikey.APIKey, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*apikey.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, "UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return internal.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*apikey.APIKey, error) {
+	var k apikey.APIKey
+	var sourceTypes []string
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &sourceTypes, &k.AccountIDs, &k.CreatedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	for _, st := range sourceTypes {
+		k.SourceTypes = append(k.SourceTypes, transaction.SourceType(st))
+	}
+	return &k, nil
+}
+
+func sourceTypeStrings(sourceTypes []transaction.SourceType) []string {
+	s := make([]string, len(sourceTypes))
+	for i, st := range sourceTypes {
+		s[i] = string(st)
+	}
+	return s
+}
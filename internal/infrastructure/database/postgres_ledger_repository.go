@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+type PostgresLedgerRepository struct {
+	db Querier
+}
+
+func NewPostgresLedgerRepository(db Querier) *PostgresLedgerRepository {
+	return &PostgresLedgerRepository{db: db}
+}
+
+func (r *PostgresLedgerRepository) Append(ctx context.Context, entry *ledger.Entry) error {
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.db.QueryRow(ctx, "SELECT nextval('ledger_entry_id_seq')").Scan(&entry.ID); err != nil {
+		return err
+	}
+
+	for i := range entry.Postings {
+		p := &entry.Postings[i]
+		p.EntryID = entry.ID
+		err := r.db.QueryRow(ctx, `
+			INSERT INTO postings (entry_id, account_id, book, direction, amount, transaction_ref, reason)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`, p.EntryID, p.AccountID, p.Book, p.Direction, p.Amount, p.TransactionRef, p.Reason).Scan(&p.ID, &p.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresLedgerRepository) Balance(ctx context.Context, accountID int64) (money.Amount, error) {
+	var balance money.Amount
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM postings
+		WHERE account_id = $1 AND book = 'player'
+	`, accountID).Scan(&balance)
+	return balance, err
+}
+
+func (r *PostgresLedgerRepository) AppendMany(ctx context.Context, entries []*ledger.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	rows, err := r.db.Query(ctx, "SELECT nextval('ledger_entry_id_seq') FROM generate_series(1, $1)", len(entries))
+	if err != nil {
+		return err
+	}
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(&entries[i].ID); err != nil {
+			rows.Close()
+			return err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Every posting of an entry is on a book of its own
+	type postingKey struct {
+		entryID int64
+		book    ledger.Book
+	}
+	postings := make(map[postingKey]*ledger.Posting)
+	var values []string
+	var args []interface{}
+	for _, entry := range entries {
+		for i := range entry.Postings {
+			p := &entry.Postings[i]
+			p.EntryID = entry.ID
+			postings[postingKey{p.EntryID, p.Book}] = p
+			values = append(values, placeholders(len(args), 7))
+			args = append(args, p.EntryID, p.AccountID, p.Book, p.Direction, p.Amount, p.TransactionRef, p.Reason)
+		}
+	}
+
+	rows, err = r.db.Query(ctx, `
+		INSERT INTO postings (entry_id, account_id, book, direction, amount, transaction_ref, reason)
+		VALUES `+strings.Join(values, ", ")+`
+		RETURNING id, entry_id, book, created_at
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var key postingKey
+		var createdAt time.Time
+		if err := rows.Scan(&id, &key.entryID, &key.book, &createdAt); err != nil {
+			return err
+		}
+		postings[key].ID, postings[key].CreatedAt = id, createdAt
+	}
+	return rows.Err()
+}
+
+// placeholders returns the parameter list "($n+1, ..., $n+count)" of one row
+// of a multi-row insert whose previous rows used n parameters.
+func placeholders(n, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", n+i+1)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// rateLimitPruneInterval is how often expired counters are deleted.
+const rateLimitPruneInterval = time.Minute
+
+// PostgresRateLimitStore shares the rate limit counters between all
+// instances using the database. Each request is a single upsert.
+type PostgresRateLimitStore struct {
+	db Querier
+
+	mu     sync.Mutex
+	pruned time.Time
+}
+
+func NewPostgresRateLimitStore(db Querier) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, policy ratelimit.Policy, now time.Time) (ratelimit.Result, error) {
+	s.prune(ctx, now)
+
+	start := now.Truncate(policy.Window)
+	end := start.Add(policy.Window)
+
+	var count int
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO rate_limits (bucket, window_start, expires_at, count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (bucket) DO UPDATE SET
+			count = CASE WHEN rate_limits.window_start = EXCLUDED.window_start THEN rate_limits.count + 1 ELSE 1 END,
+			window_start = EXCLUDED.window_start,
+			expires_at = EXCLUDED.expires_at
+		RETURNING count
+	`, key, start, end).Scan(&count)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	return ratelimit.Result{
+		Allowed:   count <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-count, 0),
+		Reset:     end,
+	}, nil
+}
+
+// prune deletes expired counters at most once per interval per instance.
+func (s *PostgresRateLimitStore) prune(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.pruned) < rateLimitPruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.pruned = now
+	s.mu.Unlock()
+
+	if _, err := s.db.Exec(ctx, "DELETE FROM rate_limits WHERE expires_at < $1", now); err != nil {
+		logger.WarnContext(ctx, "Failed to prune rate limit counters", "error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/reconciliation"
+)
+
+// The expected balance is the effect of every non-canceled transaction
+// (rollbacks have none, they cancel the transaction they reverse), plus
+// the part of canceled wins that could not be reversed (account_debts), plus
+// the postings not tied to a transaction (opening balances).
+const accountBalancesQuery = `
+	SELECT a.id,
+	       a.balance,
+	       COALESCE((SELECT SUM(CASE t.state WHEN 'win' THEN t.amount WHEN 'lost' THEN -t.amount ELSE 0 END)
+	                 FROM transactions t
+	                 WHERE t.account_id = a.id AND t.is_canceled = false), 0)
+	       + COALESCE((SELECT SUM(d.amount)
+	                   FROM account_debts d
+	                   WHERE d.account_id = a.id), 0)
+	       + COALESCE((SELECT SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END)
+	                   FROM postings p
+	                   WHERE p.account_id = a.id AND p.book = 'player' AND p.transaction_ref IS NULL), 0),
+	       COALESCE((SELECT SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END)
+	                 FROM postings p
+	                 WHERE p.account_id = a.id AND p.book = 'player'), 0)
+	FROM account a
+`
+
+type PostgresReconciliationRepository struct {
+	db Querier
+}
+
+func NewPostgresReconciliationRepository(db Querier) *PostgresReconciliationRepository {
+	return &PostgresReconciliationRepository{db: db}
+}
+
+func (r *PostgresReconciliationRepository) ListBalances(ctx context.Context, accountID int64) ([]reconciliation.AccountBalances, error) {
+	rows, err := r.db.Query(ctx, accountBalancesQuery+`
+		WHERE $1 = 0 OR a.id = $1
+		ORDER BY a.id
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []reconciliation.AccountBalances
+	for rows.Next() {
+		var b reconciliation.AccountBalances
+		if err := rows.Scan(&b.AccountID, &b.Stored, &b.Expected, &b.Ledger); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+func (r *PostgresReconciliationRepository) ListOffendingRows(ctx context.Context, accountID int64) ([]reconciliation.OffendingRow, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id,
+		       transaction_id,
+		       is_canceled,
+		       expected,
+		       posted
+		FROM (
+			SELECT t.id,
+			       t.transaction_id,
+			       t.is_canceled,
+			       CASE
+			           WHEN t.is_canceled THEN COALESCE((SELECT SUM(d.amount) FROM account_debts d WHERE d.transaction_ref = t.id), 0)
+			           WHEN t.state = 'win' THEN t.amount
+			           WHEN t.state = 'lost' THEN -t.amount
+			           ELSE 0
+			       END AS expected,
+			       COALESCE((SELECT SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END)
+			                 FROM postings p
+			                 WHERE p.transaction_ref = t.id AND p.book = 'player'), 0) AS posted
+			FROM transactions t
+			WHERE t.account_id = $1
+		) checked
+		WHERE expected <> posted
+		ORDER BY id
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	offending := []reconciliation.OffendingRow{}
+	for rows.Next() {
+		var row reconciliation.OffendingRow
+		if err := rows.Scan(&row.ID, &row.TransactionID, &row.IsCanceled, &row.Expected, &row.Posted); err != nil {
+			return nil, err
+		}
+		offending = append(offending, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return offending, nil
+}
+
+func (r *PostgresReconciliationRepository) Repair(ctx context.Context, accountID int64, note string) (*reconciliation.Audit, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Rollback in case of error
+
+	// Lock the account so no transaction is applied while it is being repaired
+	acc, err := NewPostgresAccountRepository(tx).GetByIDForUpdate(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	txRepo := NewPostgresReconciliationRepository(tx)
+	rows, err := txRepo.ListOffendingRows(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offending rows: %w", err)
+	}
+
+	ledgerRepo := NewPostgresLedgerRepository(tx)
+	for _, row := range rows {
+		ref := row.ID
+		entry := ledger.NewEntry(accountID, &ref, ledger.ReasonAdjustment, row.Difference())
+		if err := ledgerRepo.Append(ctx, &entry); err != nil {
+			return nil, fmt.Errorf("failed to post adjustment for %s: %w", row.TransactionID, err)
+		}
+	}
+
+	balances, err := txRepo.ListBalances(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute balances: %w", err)
+	}
+	if len(balances) != 1 {
+		return nil, internal.ErrAccountNotFound
+	}
+	expected := balances[0]
+
+	audit := &reconciliation.Audit{
+		AccountID:       accountID,
+		PreviousBalance: acc.Balance,
+		NewBalance:      expected.Expected,
+		LedgerBalance:   expected.Ledger,
+		AdjustedRows:    len(rows),
+		Note:            note,
+	}
+
+	acc.Balance = expected.Expected
+	acc.UpdatedAt = time.Now()
+	if err := NewPostgresAccountRepository(tx).Update(ctx, acc); err != nil {
+		return nil, fmt.Errorf("failed to update account balance: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO balance_audits (account_id, previous_balance, new_balance, ledger_balance, adjusted_rows, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, audit.AccountID, audit.PreviousBalance, audit.NewBalance, audit.LedgerBalance, audit.AdjustedRows, audit.Note).
+		Scan(&audit.ID, &audit.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record audit: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return audit, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+const roundColumns = "id, round_id, account_id, source_type, status, opened_at, closed_at"
+
+type PostgresRoundRepository struct {
+	db Querier
+}
+
+func NewPostgresRoundRepository(db Querier) *PostgresRoundRepository {
+	return &PostgresRoundRepository{db: db}
+}
+
+func (r *PostgresRoundRepository) Create(ctx context.Context, rd *round.Round) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO rounds (round_id, account_id, source_type, status, opened_at, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, rd.RoundID, rd.AccountID, rd.SourceType, rd.Status, rd.OpenedAt, rd.ClosedAt).Scan(&rd.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		// Another account opened the same round first; retrying reports it
+		return internal.ErrConcurrentModification
+	}
+	return err
+}
+
+func (r *PostgresRoundRepository) Get(ctx context.Context, sourceType transaction.SourceType, roundID string) (*round.Round, error) {
+	return r.get(ctx, "", sourceType, roundID)
+}
+
+func (r *PostgresRoundRepository) GetForUpdate(ctx context.Context, sourceType transaction.SourceType, roundID string) (*round.Round, error) {
+	return r.get(ctx, "FOR UPDATE", sourceType, roundID)
+}
+
+func (r *PostgresRoundRepository) get(ctx context.Context, lock string, sourceType transaction.SourceType, roundID string) (*round.Round, error) {
+	var rd round.Round
+	err := r.db.QueryRow(ctx, "SELECT "+roundColumns+" FROM rounds WHERE source_type = $1 AND round_id = $2 "+lock, sourceType, roundID).
+		Scan(&rd.ID, &rd.RoundID, &rd.AccountID, &rd.SourceType, &rd.Status, &rd.OpenedAt, &rd.ClosedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrRoundNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rd, nil
+}
+
+func (r *PostgresRoundRepository) Close(ctx context.Context, id int64, closedAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE rounds
+		SET status = 'closed',
+		    closed_at = $2
+		WHERE id = $1 AND status = 'open'
+	`, id, closedAt)
+	return err
+}
+
+func (r *PostgresRoundRepository) CloseOpenedBefore(ctx context.Context, cutoff, closedAt time.Time) (int64, error) {
+	// Rounds locked by a submission in progress are left for the next sweep
+	tag, err := r.db.Exec(ctx, `
+		UPDATE rounds
+		SET status = 'closed',
+		    closed_at = $2
+		WHERE id IN (
+			SELECT id
+			FROM rounds
+			WHERE status = 'open' AND opened_at < $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`, cutoff, closedAt)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// PostgresSchema reads the migration state golang-migrate keeps in the
+// schema_migrations table.
+type PostgresSchema struct {
+	db Querier
+}
+
+func NewPostgresSchema(db Querier) *PostgresSchema {
+	return &PostgresSchema{db: db}
+}
+
+// Version returns the applied migration version and whether the last
+// migration failed halfway. A database without migrations is at version 0.
+func (s *PostgresSchema) Version(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := s.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// signaturePruneInterval is how often expired signatures are deleted.
+const signaturePruneInterval = time.Minute
+
+// PostgresSignatureStore shares the signatures of accepted requests between
+// all instances using the database, and keeps them across restarts. Claims
+// rely on the primary key, so concurrent copies of a request race for one row.
+type PostgresSignatureStore struct {
+	db Querier
+
+	mu     sync.Mutex
+	pruned time.Time
+}
+
+func NewPostgresSignatureStore(db Querier) *PostgresSignatureStore {
+	return &PostgresSignatureStore{db: db}
+}
+
+func (s *PostgresSignatureStore) Claim(ctx context.Context, key string, expiresAt time.Time) (bool, error) {
+	s.prune(ctx, time.Now())
+
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO request_signatures (signature, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (signature) DO NOTHING
+	`, key, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *PostgresSignatureStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.Exec(ctx, "DELETE FROM request_signatures WHERE signature = $1", key)
+	return err
+}
+
+// prune deletes expired signatures at most once per interval per instance.
+func (s *PostgresSignatureStore) prune(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.pruned) < signaturePruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.pruned = now
+	s.mu.Unlock()
+
+	if _, err := s.db.Exec(ctx, "DELETE FROM request_signatures WHERE expires_at < $1", now); err != nil {
+		logger.WarnContext(ctx, "Failed to prune request signatures", "error", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,8 +19,17 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/internal/domain/apikey"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+	"github.com/blackcloro/transaction-processor/internal/domain/processing"
+	"github.com/blackcloro/transaction-processor/internal/domain/reconciliation"
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/internal/ratelimit"
 	"github.com/blackcloro/transaction-processor/internal/testutil"
+	"github.com/blackcloro/transaction-processor/migrations"
 )
 
 type PostgresTransactionRepositoryTestSuite struct {
@@ -26,6 +37,7 @@ type PostgresTransactionRepositoryTestSuite struct {
 	ctx         context.Context
 	pgContainer *testutil.PostgresContainer
 	repo        *PostgresTransactionRepository
+	accountRepo *PostgresAccountRepository
 }
 
 func TestPostgresTransactionRepositorySuite(t *testing.T) {
@@ -44,6 +56,7 @@ func (s *PostgresTransactionRepositoryTestSuite) SetupSuite() {
 	require.NoError(s.T(), err)
 
 	s.repo = NewPostgresTransactionRepository(s.pgContainer.Pool)
+	s.accountRepo = NewPostgresAccountRepository(s.pgContainer.Pool)
 }
 
 func (s *PostgresTransactionRepositoryTestSuite) TearDownSuite() {
@@ -54,7 +67,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TearDownSuite() {
 }
 
 func (s *PostgresTransactionRepositoryTestSuite) SetupTest() {
-	testutil.ResetAccountBalance(s.ctx, s.T(), s.pgContainer.Pool, 1, 1000)
+	testutil.ResetAccountBalance(s.ctx, s.T(), s.pgContainer.Pool, 1, money.FromInt(1000))
 	testutil.TruncateTransactions(s.ctx, s.T(), s.pgContainer.Pool)
 }
 
@@ -71,7 +84,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    transaction.SourceTypeGame,
 				State:         transaction.StateWin,
-				Amount:        100,
+				Amount:        money.FromInt(100),
 			},
 			expectedError: nil,
 		},
@@ -82,7 +95,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    transaction.SourceTypeGame,
 				State:         transaction.StateWin,
-				Amount:        100,
+				Amount:        money.FromInt(100),
 			},
 			expectedError: internal.ErrDuplicateTransaction,
 		},
@@ -93,7 +106,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "win",
-				Amount:        100,
+				Amount:        money.FromInt(100),
 			},
 			expectedError: nil,
 		},
@@ -104,7 +117,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "lost",
-				Amount:        50,
+				Amount:        money.FromInt(50),
 			},
 			expectedError: nil,
 		},
@@ -115,7 +128,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "win",
-				Amount:        100,
+				Amount:        money.FromInt(100),
 			},
 			expectedError: internal.ErrDuplicateTransaction,
 		},
@@ -126,7 +139,18 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "win",
-				Amount:        0,
+				Amount:        money.Zero,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Smallest representable amount",
+			transaction: &transaction.Transaction{
+				TransactionID: "tiny-1",
+				AccountID:     1,
+				SourceType:    "game",
+				State:         "win",
+				Amount:        money.MustParse("0.00001"),
 			},
 			expectedError: nil,
 		},
@@ -137,7 +161,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "win",
-				Amount:        999999.99,
+				Amount:        money.MustParse("999999.99"),
 			},
 			expectedError: nil,
 		},
@@ -148,7 +172,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 				AccountID:     1,
 				SourceType:    "game",
 				State:         "win",
-				Amount:        1e10,
+				Amount:        money.FromInt(1e10),
 			},
 			expectedError: internal.ErrNumericOverflow,
 		},
@@ -163,7 +187,7 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 			} else {
 				s.NoError(err)
 				// Verify the transaction was created
-				storedTx, err := s.repo.GetByID(s.ctx, tc.transaction.TransactionID)
+				storedTx, err := s.repo.GetByID(s.ctx, tc.transaction.SourceType, tc.transaction.TransactionID)
 				s.NoError(err)
 				s.Equal(tc.transaction.TransactionID, storedTx.TransactionID)
 				s.Equal(tc.transaction.AccountID, storedTx.AccountID)
@@ -175,47 +199,805 @@ func (s *PostgresTransactionRepositoryTestSuite) TestCreate() {
 	}
 }
 
-func (s *PostgresTransactionRepositoryTestSuite) TestGetLatestOddRecords() {
-	// Create some test transactions
-	transactions := testutil.GenerateTransactions(20)
+func (s *PostgresTransactionRepositoryTestSuite) TestPostProcessPolicies() {
+	transactions := testutil.GenerateTransactions(30)
+	for i := range transactions {
+		err := s.repo.Create(s.ctx, &transactions[i])
+		s.Require().NoError(err)
+	}
+
+	// A dry run reports the odd rows of the latest batch without canceling anything
+	dryRun := transaction.NewService(s.repo, transaction.PostProcessConfig{DryRun: true})
+	results, err := dryRun.PostProcess(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Len(results[0].Selected, transaction.DefaultBatchSize/2)
+	s.Equal(transactions[29].ID, results[0].Selected[0])
+	s.Nil(results[0].Cancellation)
+
+	canceled := true
+	page, err := dryRun.ListTransactions(s.ctx, transaction.Filter{AccountID: 1, IsCanceled: &canceled})
+	s.Require().NoError(err)
+	s.Empty(page.Transactions)
+
+	// Selection criteria narrow the candidates handed to the policy
+	sourceType := transaction.SourceTypeGame
+	minAmount := money.FromInt(280)
+	service := transaction.NewService(s.repo, transaction.PostProcessConfig{
+		Policy:    transaction.CancelAllPolicy{},
+		Selection: transaction.Selection{SourceType: &sourceType, MinAmount: &minAmount},
+	})
+	results, err = service.PostProcess(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Equal([]int64{transactions[29].ID, transactions[28].ID, transactions[27].ID}, results[0].Selected)
+	s.Require().NotNil(results[0].Cancellation)
+	s.Len(results[0].Cancellation.Canceled, 3)
+
+	// Nothing has been processed long enough ago
+	aged := transaction.NewService(s.repo, transaction.PostProcessConfig{
+		Selection: transaction.Selection{MinAge: time.Hour},
+	})
+	results, err = aged.PostProcess(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Empty(results[0].Selected)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestListTransactions() {
+	// GenerateTransactions leaves ProcessedAt identical, so ordering relies on the id tie-breaker
+	transactions := testutil.GenerateTransactions(25)
 	for _, tx := range transactions {
 		err := s.repo.Create(s.ctx, &tx)
 		s.Require().NoError(err)
 	}
 
-	// Test fetching latest odd records
-	limit := 5
-	oddRecords, err := s.repo.GetLatestOddRecords(s.ctx, limit)
+	service := transaction.NewService(s.repo, transaction.PostProcessConfig{})
+	seen := make(map[int64]bool)
+	filter := transaction.Filter{AccountID: 1, Limit: 10}
+	var pages int
+	for {
+		page, err := service.ListTransactions(s.ctx, filter)
+		s.Require().NoError(err)
+		pages++
+		for _, tx := range page.Transactions {
+			s.False(seen[tx.ID], "transaction returned twice")
+			seen[tx.ID] = true
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		filter.After = page.NextCursor
+	}
+	s.Equal(3, pages)
+	s.Len(seen, 25)
+
+	minAmount := money.FromInt(100)
+	canceled := false
+	page, err := service.ListTransactions(s.ctx, transaction.Filter{AccountID: 1, MinAmount: &minAmount, IsCanceled: &canceled})
 	s.Require().NoError(err)
-	s.Len(oddRecords, limit)
+	s.Len(page.Transactions, 16)
+	s.Nil(page.NextCursor)
 
-	// Verify that only odd-numbered records are returned
-	for _, tx := range oddRecords {
-		s.True(tx.ID%2 == 1, "Expected only odd-numbered records")
-	}
+	// Transactions are only visible through the account owning them
+	_, err = service.GetTransaction(s.ctx, 2, transactions[0].SourceType, transactions[0].TransactionID)
+	s.ErrorIs(err, internal.ErrTransactionNotFound)
 }
 
 func (s *PostgresTransactionRepositoryTestSuite) TestMarkAsCanceled() {
 	// Create some test transactions
 	transactions := testutil.GenerateTransactions(10)
-	for _, tx := range transactions {
-		err := s.repo.Create(s.ctx, &tx)
+	for i := range transactions {
+		err := s.repo.Create(s.ctx, &transactions[i])
 		s.Require().NoError(err)
 	}
 
 	// Mark some transactions as canceled
-	idsToCancel := []string{transactions[0].TransactionID, transactions[2].TransactionID, transactions[4].TransactionID}
-	err := s.repo.MarkAsCanceled(s.ctx, idsToCancel)
+	toCancel := []transaction.Transaction{transactions[0], transactions[2], transactions[4]}
+	ids := make([]int64, len(toCancel))
+	for i, tx := range toCancel {
+		ids[i] = tx.ID
+	}
+	result, err := s.repo.MarkAsCanceled(s.ctx, 1, ids, transaction.OverdraftSkip)
 	s.Require().NoError(err)
+	s.Len(result.Canceled, 3)
 
 	// Verify that the transactions are marked as canceled
-	for _, id := range idsToCancel {
-		tx, err := s.repo.GetByID(s.ctx, id)
+	for _, canceled := range toCancel {
+		tx, err := s.repo.GetByID(s.ctx, canceled.SourceType, canceled.TransactionID)
 		s.Require().NoError(err)
 		s.True(tx.IsCanceled, "Expected transaction to be marked as canceled")
 	}
 }
 
+func (s *PostgresTransactionRepositoryTestSuite) TestMarkAsCanceledScopedByAccount() {
+	other := &account.Account{}
+	s.Require().NoError(s.accountRepo.Create(s.ctx, other))
+
+	transactions := testutil.GenerateTransactions(4)
+	for i := range transactions {
+		if i%2 == 1 {
+			transactions[i].AccountID = other.ID
+		}
+		err := s.repo.Create(s.ctx, &transactions[i])
+		s.Require().NoError(err)
+	}
+
+	accountIDs, err := s.repo.ListAccountIDs(s.ctx)
+	s.Require().NoError(err)
+	s.Contains(accountIDs, int64(1))
+	s.Contains(accountIDs, other.ID)
+
+	// Cancelling through account 1 must not touch rows owned by the other account
+	ids := []int64{transactions[0].ID, transactions[1].ID}
+	result, err := s.repo.MarkAsCanceled(s.ctx, 1, ids, transaction.OverdraftSkip)
+	s.Require().NoError(err)
+	s.Equal([]int64{transactions[1].ID}, result.NotFound)
+
+	own, err := s.repo.GetByID(s.ctx, transactions[0].SourceType, transactions[0].TransactionID)
+	s.Require().NoError(err)
+	s.True(own.IsCanceled)
+
+	foreign, err := s.repo.GetByID(s.ctx, transactions[1].SourceType, transactions[1].TransactionID)
+	s.Require().NoError(err)
+	s.False(foreign.IsCanceled)
+
+	// Post-processing only selects transactions of the account it runs for
+	service := transaction.NewService(s.repo, transaction.PostProcessConfig{DryRun: true})
+	results, err := service.PostProcess(s.ctx)
+	s.Require().NoError(err)
+	owners := make(map[int64]int64, len(transactions))
+	for _, tx := range transactions {
+		owners[tx.ID] = tx.AccountID
+	}
+	for _, result := range results {
+		for _, id := range result.Selected {
+			s.Equal(result.AccountID, owners[id])
+		}
+	}
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestUnitOfWorkRollsBackOnError() {
+	uow := NewPostgresUnitOfWork(s.pgContainer.Pool)
+	errBoom := errors.New("boom")
+
+	tx := testutil.GenerateTransactions(1)[0]
+	err := uow.Do(s.ctx, func(ctx context.Context, repos processing.Repositories) error {
+		s.Require().NoError(repos.Transactions.Create(ctx, &tx))
+		return errBoom
+	})
+	s.ErrorIs(err, errBoom)
+
+	// The insert must not survive the failed unit of work
+	_, err = s.repo.GetByID(s.ctx, tx.SourceType, tx.TransactionID)
+	s.ErrorIs(err, internal.ErrTransactionNotFound)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestProcessTransaction() {
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+
+	win := &transaction.Transaction{
+		TransactionID: "uow-win",
+		AccountID:     1,
+		SourceType:    transaction.SourceTypeGame,
+		State:         transaction.StateWin,
+		Amount:        money.MustParse("10.15"),
+	}
+	result, err := service.ProcessTransaction(s.ctx, win)
+	s.Require().NoError(err)
+	s.Equal(money.MustParse("1010.15"), result.Balance)
+
+	// A resubmission with the same payload gets the original result back
+	retry := &transaction.Transaction{
+		TransactionID: "uow-win",
+		AccountID:     1,
+		SourceType:    transaction.SourceTypeGame,
+		State:         transaction.StateWin,
+		Amount:        money.MustParse("10.150"),
+	}
+	replayed, err := service.ProcessTransaction(s.ctx, retry)
+	s.Require().NoError(err)
+	s.True(replayed.Replayed)
+	s.Equal(result.Transaction.ID, replayed.Transaction.ID)
+	s.Equal(money.MustParse("1010.15"), replayed.Balance)
+
+	// One with a different payload is a conflict listing the differences
+	conflicting := *retry
+	conflicting.Amount = money.FromInt(20)
+	_, err = service.ProcessTransaction(s.ctx, &conflicting)
+	s.ErrorIs(err, internal.ErrDuplicateTransaction)
+	var duplicate *transaction.DuplicateError
+	s.Require().ErrorAs(err, &duplicate)
+	s.Equal([]transaction.FieldDiff{{Field: "amount", Stored: "10.15", Received: "20.00"}}, duplicate.Diff)
+
+	loss := &transaction.Transaction{
+		TransactionID: "uow-loss",
+		AccountID:     1,
+		SourceType:    transaction.SourceTypeGame,
+		State:         transaction.StateLost,
+		Amount:        money.FromInt(5000),
+	}
+	_, err = service.ProcessTransaction(s.ctx, loss)
+	s.ErrorIs(err, internal.ErrInsufficientFunds)
+
+	// Neither the rejected transaction nor a balance change may be persisted
+	_, err = s.repo.GetByID(s.ctx, loss.SourceType, loss.TransactionID)
+	s.ErrorIs(err, internal.ErrTransactionNotFound)
+
+	acc, err := s.accountRepo.GetByID(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(money.MustParse("1010.15"), acc.Balance)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestTransactionIDsAreScopedPerProvider() {
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+
+	game := &transaction.Transaction{TransactionID: "shared-1", AccountID: 1, SourceType: transaction.SourceTypeGame, State: transaction.StateWin, Amount: money.FromInt(10)}
+	payment := &transaction.Transaction{TransactionID: "shared-1", AccountID: 1, SourceType: transaction.SourceTypePayment, State: transaction.StateWin, Amount: money.FromInt(20)}
+
+	_, err := service.ProcessTransaction(s.ctx, game)
+	s.Require().NoError(err)
+	result, err := service.ProcessTransaction(s.ctx, payment)
+	s.Require().NoError(err)
+	s.False(result.Replayed, "another provider's transaction with the same ID is a new transaction")
+	s.Equal(money.FromInt(1030), result.Balance)
+
+	stored, err := s.repo.GetByID(s.ctx, transaction.SourceTypePayment, "shared-1")
+	s.Require().NoError(err)
+	s.Equal(money.FromInt(20), stored.Amount)
+
+	_, err = s.repo.GetByID(s.ctx, transaction.SourceTypeServer, "shared-1")
+	s.ErrorIs(err, internal.ErrTransactionNotFound)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestProcessBatch() {
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	batch := func(txs ...*transaction.Transaction) []*transaction.Transaction {
+		for _, tx := range txs {
+			tx.AccountID = 1
+			tx.SourceType = transaction.SourceTypeGame
+		}
+		return txs
+	}
+
+	// One rejected transaction rejects the whole atomic batch
+	result, err := service.ProcessBatch(s.ctx, processing.BatchAtomic, batch(
+		&transaction.Transaction{TransactionID: "batch-1", State: transaction.StateWin, Amount: money.FromInt(10)},
+		&transaction.Transaction{TransactionID: "batch-2", State: transaction.StateLost, Amount: money.FromInt(5000)},
+	))
+	s.Require().NoError(err)
+	s.False(result.Committed)
+	s.Equal(processing.ItemAborted, result.Items[0].Status)
+	s.Equal(processing.ItemInsufficientFunds, result.Items[1].Status)
+	_, err = s.repo.GetByID(s.ctx, transaction.SourceTypeGame, "batch-1")
+	s.ErrorIs(err, internal.ErrTransactionNotFound)
+
+	// Best effort stores whatever can be stored
+	result, err = service.ProcessBatch(s.ctx, processing.BatchBestEffort, batch(
+		&transaction.Transaction{TransactionID: "batch-1", State: transaction.StateWin, Amount: money.FromInt(10)},
+		&transaction.Transaction{TransactionID: "batch-2", State: transaction.StateLost, Amount: money.FromInt(5000)},
+		&transaction.Transaction{TransactionID: "batch-3", State: transaction.StateLost, Amount: money.FromInt(3)},
+		&transaction.Transaction{TransactionID: "batch-3", State: transaction.StateLost, Amount: money.FromInt(3)},
+		&transaction.Transaction{TransactionID: "", State: transaction.StateWin, Amount: money.FromInt(1)},
+	))
+	s.Require().NoError(err)
+	s.True(result.Committed)
+	s.Equal(money.FromInt(1007), result.Balance)
+	statuses := make([]processing.ItemStatus, len(result.Items))
+	for i, item := range result.Items {
+		statuses[i] = item.Status
+	}
+	s.Equal([]processing.ItemStatus{
+		processing.ItemCreated, processing.ItemInsufficientFunds, processing.ItemCreated, processing.ItemDuplicate, processing.ItemInvalid,
+	}, statuses)
+	s.NotZero(result.Items[0].Transaction.ID)
+	s.NotEqual(result.Items[0].Transaction.ID, result.Items[2].Transaction.ID)
+
+	stored, err := s.repo.GetByID(s.ctx, transaction.SourceTypeGame, "batch-3")
+	s.Require().NoError(err)
+	s.Equal(money.FromInt(1007), *stored.BalanceAfter)
+
+	// Resubmitting a stored transaction replays it, while a changed one is rejected
+	result, err = service.ProcessBatch(s.ctx, processing.BatchAtomic, batch(
+		&transaction.Transaction{TransactionID: "batch-1", State: transaction.StateWin, Amount: money.FromInt(10)},
+		&transaction.Transaction{TransactionID: "batch-4", State: transaction.StateWin, Amount: money.FromInt(1)},
+	))
+	s.Require().NoError(err)
+	s.True(result.Committed)
+	s.Equal(processing.ItemDuplicate, result.Items[0].Status)
+	s.Equal(money.FromInt(1010), *result.Items[0].Transaction.BalanceAfter)
+	s.Equal(processing.ItemCreated, result.Items[1].Status)
+
+	result, err = service.ProcessBatch(s.ctx, processing.BatchAtomic, batch(
+		&transaction.Transaction{TransactionID: "batch-1", State: transaction.StateWin, Amount: money.FromInt(11)},
+	))
+	s.Require().NoError(err)
+	s.False(result.Committed)
+	s.NotEmpty(result.Items[0].Diff)
+
+	// Every stored transaction was posted to the ledger
+	verification, err := ledger.NewService(NewPostgresLedgerRepository(s.pgContainer.Pool), s.accountRepo).Verify(s.ctx, 1)
+	s.Require().NoError(err)
+	s.True(verification.Balanced())
+	s.Equal(money.FromInt(1008), verification.Stored)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestAccountUpdateDetectsConcurrentModification() {
+	first, err := s.accountRepo.GetByID(s.ctx, 1)
+	s.Require().NoError(err)
+	second, err := s.accountRepo.GetByID(s.ctx, 1)
+	s.Require().NoError(err)
+
+	first.Balance = first.Balance.Add(money.FromInt(1))
+	s.Require().NoError(s.accountRepo.Update(s.ctx, first))
+	s.Equal(second.Version+1, first.Version)
+
+	// second still carries the old version and must not overwrite first's update
+	second.Balance = second.Balance.Add(money.FromInt(2))
+	s.ErrorIs(s.accountRepo.Update(s.ctx, second), internal.ErrConcurrentModification)
+
+	stored, err := s.accountRepo.GetByID(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(money.FromInt(1001), stored.Balance)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestProcessTransactionConcurrently() {
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+
+	const requests = 300
+	errs := make(chan error, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx := &transaction.Transaction{
+				TransactionID: fmt.Sprintf("concurrent-%d", i),
+				AccountID:     1,
+				SourceType:    transaction.SourceTypeGame,
+				State:         transaction.StateWin,
+				Amount:        money.MustParse("2.5"),
+			}
+			if i%2 == 1 {
+				tx.State = transaction.StateLost
+				tx.Amount = money.FromInt(1)
+			}
+			_, err := service.ProcessTransaction(s.ctx, tx)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		s.NoError(err)
+	}
+
+	// 150 wins of 2.5 and 150 losses of 1 on top of the initial 1000
+	acc, err := s.accountRepo.GetByID(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(money.MustParse("1225"), acc.Balance)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestAccountStatement() {
+	processingService := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	accountService := account.NewService(s.accountRepo)
+	from := time.Now().Add(-time.Second)
+
+	ids := make(map[string]int64)
+	for _, tx := range []*transaction.Transaction{
+		{TransactionID: "stmt-1", State: transaction.StateWin, Amount: money.FromInt(10)},
+		{TransactionID: "stmt-2", State: transaction.StateLost, Amount: money.FromInt(3)},
+		{TransactionID: "stmt-3", State: transaction.StateWin, Amount: money.FromInt(5)},
+	} {
+		tx.AccountID = 1
+		tx.SourceType = transaction.SourceTypeGame
+		result, err := processingService.ProcessTransaction(s.ctx, tx)
+		s.Require().NoError(err)
+		ids[tx.TransactionID] = result.Transaction.ID
+	}
+	_, err := s.repo.MarkAsCanceled(s.ctx, 1, []int64{ids["stmt-1"]}, transaction.OverdraftSkip)
+	s.Require().NoError(err)
+
+	statement, err := accountService.GetStatement(s.ctx, 1, from, time.Now().Add(time.Minute))
+	s.Require().NoError(err)
+	s.Equal(money.FromInt(1000), statement.OpeningBalance)
+	s.Equal(money.FromInt(1002), statement.ClosingBalance)
+	s.Require().Len(statement.Entries, 4)
+
+	last := statement.Entries[3]
+	s.Equal("stmt-1", last.TransactionID)
+	s.Equal(account.MovementCanceled, last.Kind)
+	s.Equal(money.FromInt(-10), last.Change)
+	s.Equal(money.FromInt(1002), last.Balance)
+
+	balance, err := accountService.GetBalance(s.ctx, 1)
+	s.Require().NoError(err)
+	s.Equal(statement.ClosingBalance, balance.Balance)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestLedgerTracksEveryBalanceChange() {
+	acc := &account.Account{}
+	s.Require().NoError(s.accountRepo.Create(s.ctx, acc))
+
+	processingService := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	ledgerRepo := NewPostgresLedgerRepository(s.pgContainer.Pool)
+	ledgerService := ledger.NewService(ledgerRepo, s.accountRepo)
+
+	ids := make(map[string]int64)
+	for _, tx := range []*transaction.Transaction{
+		{TransactionID: "ledger-1", State: transaction.StateWin, Amount: money.MustParse("20.5")},
+		{TransactionID: "ledger-2", State: transaction.StateLost, Amount: money.FromInt(4)},
+		{TransactionID: "ledger-3", State: transaction.StateWin, Amount: money.FromInt(1)},
+	} {
+		tx.AccountID = acc.ID
+		tx.SourceType = transaction.SourceTypeGame
+		result, err := processingService.ProcessTransaction(s.ctx, tx)
+		s.Require().NoError(err)
+		ids[tx.TransactionID] = result.Transaction.ID
+	}
+	_, err := s.repo.MarkAsCanceled(s.ctx, acc.ID, []int64{ids["ledger-2"]}, transaction.OverdraftSkip)
+	s.Require().NoError(err)
+
+	verification, err := ledgerService.Verify(s.ctx, acc.ID)
+	s.Require().NoError(err)
+	s.True(verification.Balanced())
+	s.Equal(money.MustParse("21.5"), verification.Derived)
+
+	// A balance changed behind the ledger's back is detected and can be rebuilt
+	testutil.ResetAccountBalance(s.ctx, s.T(), s.pgContainer.Pool, int(acc.ID), money.FromInt(500))
+	verification, err = ledgerService.Verify(s.ctx, acc.ID)
+	s.Require().NoError(err)
+	s.False(verification.Balanced())
+
+	rebuilt, err := ledgerService.Rebuild(s.ctx, acc.ID)
+	s.Require().NoError(err)
+	s.Equal(money.MustParse("21.5"), rebuilt.Balance)
+
+	// Postings are append-only
+	_, err = s.pgContainer.Pool.Exec(s.ctx, "DELETE FROM postings WHERE account_id = $1", acc.ID)
+	s.Error(err)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestReconciliationRepairsUnreversedCancellation() {
+	acc := &account.Account{}
+	s.Require().NoError(s.accountRepo.Create(s.ctx, acc))
+
+	processingService := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	for _, tx := range []*transaction.Transaction{
+		{TransactionID: "recon-1", State: transaction.StateWin, Amount: money.FromInt(10)},
+		{TransactionID: "recon-2", State: transaction.StateWin, Amount: money.FromInt(5)},
+	} {
+		tx.AccountID = acc.ID
+		tx.SourceType = transaction.SourceTypeGame
+		_, err := processingService.ProcessTransaction(s.ctx, tx)
+		s.Require().NoError(err)
+	}
+
+	// Flag a row as canceled without reversing it, as the old non-atomic code could
+	_, err := s.pgContainer.Pool.Exec(s.ctx, "UPDATE transactions SET is_canceled = true WHERE transaction_id = 'recon-2'")
+	s.Require().NoError(err)
+
+	service := reconciliation.NewService(NewPostgresReconciliationRepository(s.pgContainer.Pool))
+	report, err := service.Run(s.ctx, reconciliation.Options{AccountID: acc.ID})
+	s.Require().NoError(err)
+	s.Equal(1, report.Checked)
+	s.Require().Len(report.Discrepancies, 1)
+
+	discrepancy := report.Discrepancies[0]
+	s.Equal(money.FromInt(15), discrepancy.Stored)
+	s.Equal(money.FromInt(10), discrepancy.Expected)
+	s.Require().Len(discrepancy.OffendingRows, 1)
+	s.Equal("recon-2", discrepancy.OffendingRows[0].TransactionID)
+	s.Equal(money.FromInt(5), discrepancy.OffendingRows[0].Posted)
+	s.False(report.Clean())
+
+	report, err = service.Run(s.ctx, reconciliation.Options{AccountID: acc.ID, Repair: true, Note: "test"})
+	s.Require().NoError(err)
+	s.Require().Len(report.Repairs, 1)
+	s.Equal(money.FromInt(15), report.Repairs[0].PreviousBalance)
+	s.Equal(money.FromInt(10), report.Repairs[0].NewBalance)
+	s.Equal(money.FromInt(10), report.Repairs[0].LedgerBalance)
+	s.True(report.Clean())
+
+	report, err = service.Run(s.ctx, reconciliation.Options{AccountID: acc.ID})
+	s.Require().NoError(err)
+	s.Empty(report.Discrepancies)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestRollbackReversesOriginalOnce() {
+	acc := &account.Account{}
+	s.Require().NoError(s.accountRepo.Create(s.ctx, acc))
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	submit := func(tx *transaction.Transaction) (*processing.Result, error) {
+		tx.AccountID = acc.ID
+		if tx.SourceType == "" {
+			tx.SourceType = transaction.SourceTypeGame
+		}
+		return service.ProcessTransaction(s.ctx, tx)
+	}
+	rollback := func(id, original string, amount money.Amount) *transaction.Transaction {
+		return &transaction.Transaction{TransactionID: id, State: transaction.StateRollback, Amount: amount, OriginalTransactionID: original}
+	}
+
+	_, err := submit(&transaction.Transaction{TransactionID: "rb-win", State: transaction.StateWin, Amount: money.FromInt(10)})
+	s.Require().NoError(err)
+	_, err = submit(&transaction.Transaction{TransactionID: "rb-loss", State: transaction.StateLost, Amount: money.FromInt(4)})
+	s.Require().NoError(err)
+
+	result, err := submit(rollback("rb-1", "rb-loss", money.FromInt(4)))
+	s.Require().NoError(err)
+	s.Equal(money.FromInt(10), result.Balance)
+
+	// The pair is linked both ways
+	original, err := s.repo.GetByID(s.ctx, transaction.SourceTypeGame, "rb-loss")
+	s.Require().NoError(err)
+	s.True(original.IsCanceled)
+	s.Require().NotNil(original.ReversedBy)
+	s.Equal(result.Transaction.ID, *original.ReversedBy)
+	stored, err := s.repo.GetByID(s.ctx, transaction.SourceTypeGame, "rb-1")
+	s.Require().NoError(err)
+	s.Equal(original.ID, *stored.Reverses)
+	s.Equal("rb-loss", stored.OriginalTransactionID)
+
+	// Resubmitting the rollback replays it, another one for the same original is rejected
+	replayed, err := submit(rollback("rb-1", "rb-loss", money.FromInt(4)))
+	s.Require().NoError(err)
+	s.True(replayed.Replayed)
+	_, err = submit(rollback("rb-2", "rb-loss", money.FromInt(4)))
+	s.ErrorIs(err, internal.ErrAlreadyReversed)
+
+	_, err = submit(rollback("rb-3", "missing", money.FromInt(4)))
+	s.ErrorIs(err, internal.ErrOriginalNotFound)
+	other := rollback("rb-3", "rb-win", money.FromInt(10))
+	other.SourceType = transaction.SourceTypePayment
+	_, err = submit(other)
+	s.ErrorIs(err, internal.ErrOriginalNotFound)
+	_, err = submit(rollback("rb-3", "rb-win", money.FromInt(9)))
+	s.ErrorIs(err, internal.ErrRollbackMismatch)
+
+	// The worker cannot reverse the original again, nor cancel the rollback
+	cancellation, err := s.repo.MarkAsCanceled(s.ctx, acc.ID, []int64{original.ID, stored.ID}, transaction.OverdraftSkip)
+	s.Require().NoError(err)
+	s.Equal([]int64{original.ID}, cancellation.AlreadyCanceled)
+	s.Equal([]int64{stored.ID}, cancellation.NotFound)
+
+	report, err := reconciliation.NewService(NewPostgresReconciliationRepository(s.pgContainer.Pool)).
+		Run(s.ctx, reconciliation.Options{AccountID: acc.ID})
+	s.Require().NoError(err)
+	s.Empty(report.Discrepancies)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestRounds() {
+	acc := &account.Account{}
+	s.Require().NoError(s.accountRepo.Create(s.ctx, acc))
+	service := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+	roundRepo := NewPostgresRoundRepository(s.pgContainer.Pool)
+	submit := func(id string, state transaction.State, amount int64, roundID string) error {
+		_, err := service.ProcessTransaction(s.ctx, &transaction.Transaction{
+			TransactionID: id, AccountID: acc.ID, SourceType: transaction.SourceTypeGame,
+			State: state, Amount: money.FromInt(amount), RoundID: roundID,
+		})
+		return err
+	}
+
+	s.Require().NoError(submit("round-deposit", transaction.StateWin, 100, ""))
+	s.ErrorIs(submit("round-win-0", transaction.StateWin, 5, "round-1"), internal.ErrRoundNotFound)
+
+	// Bets open the round and join it, the win settles and closes it
+	s.Require().NoError(submit("round-bet-1", transaction.StateLost, 10, "round-1"))
+	s.Require().NoError(submit("round-bet-2", transaction.StateLost, 5, "round-1"))
+	s.Require().NoError(submit("round-win-1", transaction.StateWin, 30, "round-1"))
+	s.ErrorIs(submit("round-bet-3", transaction.StateLost, 5, "round-1"), internal.ErrRoundClosed)
+
+	rd, err := roundRepo.Get(s.ctx, transaction.SourceTypeGame, "round-1")
+	s.Require().NoError(err)
+	s.Equal(round.StatusClosed, rd.Status)
+	s.NotNil(rd.ClosedAt)
+
+	// A failed bet opens nothing
+	s.ErrorIs(submit("round-bet-4", transaction.StateLost, 1000, "round-2"), internal.ErrInsufficientFunds)
+	_, err = roundRepo.Get(s.ctx, transaction.SourceTypeGame, "round-2")
+	s.ErrorIs(err, internal.ErrRoundNotFound)
+
+	// Another account cannot join the round
+	_, err = service.ProcessTransaction(s.ctx, &transaction.Transaction{
+		TransactionID: "round-other", AccountID: 1, SourceType: transaction.SourceTypeGame,
+		State: transaction.StateLost, Amount: money.FromInt(1), RoundID: "round-1",
+	})
+	s.ErrorIs(err, internal.ErrRoundNotFound)
+
+	roundID := "round-1"
+	transactions, err := s.repo.List(s.ctx, transaction.Filter{AccountID: acc.ID, RoundID: &roundID, Limit: 10})
+	s.Require().NoError(err)
+	s.Len(transactions, 3)
+	s.Equal("round-1", transactions[0].RoundID)
+
+	// A batch sees the round it opens itself
+	result, err := service.ProcessBatch(s.ctx, processing.BatchAtomic, []*transaction.Transaction{
+		{TransactionID: "round-bet-5", AccountID: acc.ID, SourceType: transaction.SourceTypeGame, State: transaction.StateLost, Amount: money.FromInt(1), RoundID: "round-3"},
+		{TransactionID: "round-win-3", AccountID: acc.ID, SourceType: transaction.SourceTypeGame, State: transaction.StateWin, Amount: money.FromInt(2), RoundID: "round-3"},
+		{TransactionID: "round-bet-6", AccountID: acc.ID, SourceType: transaction.SourceTypeGame, State: transaction.StateLost, Amount: money.FromInt(1), RoundID: "round-4"},
+	})
+	s.Require().NoError(err)
+	s.True(result.Committed)
+	rd, err = roundRepo.Get(s.ctx, transaction.SourceTypeGame, "round-3")
+	s.Require().NoError(err)
+	s.Equal(round.StatusClosed, rd.Status)
+
+	// Rounds left open for longer than the timeout are closed
+	closed, err := round.NewService(roundRepo).CloseStale(s.ctx, 0)
+	s.Require().NoError(err)
+	s.Equal(int64(1), closed)
+	s.ErrorIs(submit("round-win-4", transaction.StateWin, 1, "round-4"), internal.ErrRoundClosed)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestCancellationOverdraftPolicies() {
+	reconciliationService := reconciliation.NewService(NewPostgresReconciliationRepository(s.pgContainer.Pool))
+	processingService := processing.NewService(NewPostgresUnitOfWork(s.pgContainer.Pool))
+
+	testCases := []struct {
+		policy           transaction.OverdraftPolicy
+		expectedCanceled int
+		expectedBalance  money.Amount
+		expectedDebt     money.Amount
+	}{
+		{policy: transaction.OverdraftSkip, expectedCanceled: 0, expectedBalance: money.FromInt(2), expectedDebt: money.Zero},
+		{policy: transaction.OverdraftPartial, expectedCanceled: 1, expectedBalance: money.Zero, expectedDebt: money.Zero},
+		{policy: transaction.OverdraftClamp, expectedCanceled: 1, expectedBalance: money.Zero, expectedDebt: money.FromInt(8)},
+	}
+
+	for _, tc := range testCases {
+		s.Run(string(tc.policy), func() {
+			acc := &account.Account{}
+			s.Require().NoError(s.accountRepo.Create(s.ctx, acc))
+
+			win := &transaction.Transaction{TransactionID: fmt.Sprintf("overdraft-%s-win", tc.policy), State: transaction.StateWin, Amount: money.FromInt(10)}
+			loss := &transaction.Transaction{TransactionID: win.TransactionID + "-loss", State: transaction.StateLost, Amount: money.FromInt(8)}
+			for _, tx := range []*transaction.Transaction{win, loss} {
+				tx.AccountID = acc.ID
+				tx.SourceType = transaction.SourceTypeGame
+				_, err := processingService.ProcessTransaction(s.ctx, tx)
+				s.Require().NoError(err)
+			}
+
+			// Reversing the win of 10 needs more than the remaining balance of 2
+			result, err := s.repo.MarkAsCanceled(s.ctx, acc.ID, []int64{win.ID}, tc.policy)
+			s.Require().NoError(err)
+			s.Len(result.Canceled, tc.expectedCanceled)
+			s.Equal(tc.expectedDebt, result.Debt)
+			if tc.policy == transaction.OverdraftSkip {
+				s.Equal([]int64{win.ID}, result.Skipped)
+			} else {
+				s.Equal(money.FromInt(-2), result.Canceled[0].Reversed)
+				s.Equal(money.FromInt(8), result.Canceled[0].Shortfall)
+			}
+
+			stored, err := s.accountRepo.GetByID(s.ctx, acc.ID)
+			s.Require().NoError(err)
+			s.Equal(tc.expectedBalance, stored.Balance)
+
+			// A second run must not reverse the same row again
+			result, err = s.repo.MarkAsCanceled(s.ctx, acc.ID, []int64{win.ID}, tc.policy)
+			s.Require().NoError(err)
+			s.Empty(result.Canceled)
+			if tc.policy != transaction.OverdraftSkip {
+				s.Equal([]int64{win.ID}, result.AlreadyCanceled)
+			}
+
+			report, err := reconciliationService.Run(s.ctx, reconciliation.Options{AccountID: acc.ID})
+			s.Require().NoError(err)
+			s.Empty(report.Discrepancies)
+		})
+	}
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestAdvisoryLockElectsOneLeader() {
+	first := NewPostgresAdvisoryLock(s.pgContainer.Pool, 42, "instance-a", time.Second)
+	second := NewPostgresAdvisoryLock(s.pgContainer.Pool, 42, "instance-b", time.Second)
+
+	_, ok, err := first.TryLock(s.ctx)
+	s.Require().NoError(err)
+	s.True(ok)
+
+	_, ok, err = second.TryLock(s.ctx)
+	s.Require().NoError(err)
+	s.False(ok, "only one instance may hold the lock")
+
+	holder, err := second.Holder(s.ctx)
+	s.Require().NoError(err)
+	s.Equal("instance-a", holder)
+
+	s.Require().NoError(first.Unlock(s.ctx))
+
+	_, ok, err = second.TryLock(s.ctx)
+	s.Require().NoError(err)
+	s.True(ok, "the lock must be free once released")
+	s.Require().NoError(second.Unlock(s.ctx))
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestAPIKeys() {
+	service := apikey.NewService(NewPostgresAPIKeyRepository(s.pgContainer.Pool))
+
+	key := &apikey.APIKey{Name: "slots", SourceTypes: []transaction.SourceType{transaction.SourceTypeGame}, AccountIDs: []int64{1}}
+	secret, err := service.CreateKey(s.ctx, key)
+	s.Require().NoError(err)
+	s.True(strings.HasPrefix(secret, key.Prefix))
+
+	found, err := service.Authenticate(s.ctx, secret)
+	s.Require().NoError(err)
+	s.Equal(key.ID, found.ID)
+	s.True(found.AllowsSourceType(transaction.SourceTypeGame))
+	s.False(found.AllowsSourceType(transaction.SourceTypePayment), "a game key must not submit payments")
+	s.True(found.AllowsAccount(1))
+	s.False(found.AllowsAccount(2))
+
+	_, err = service.Authenticate(s.ctx, secret+"x")
+	s.ErrorIs(err, internal.ErrInvalidAPIKey)
+
+	s.Require().NoError(service.RevokeKey(s.ctx, key.ID))
+	_, err = service.Authenticate(s.ctx, secret)
+	s.ErrorIs(err, internal.ErrInvalidAPIKey, "revoked keys must be rejected")
+	s.ErrorIs(service.RevokeKey(s.ctx, key.ID), internal.ErrAPIKeyNotFound)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestRateLimitStoreSharesCounters() {
+	policy := ratelimit.Policy{Limit: 2, Window: time.Minute}
+	now := time.Now().Truncate(time.Minute)
+	// Two instances count against the same buckets
+	first := NewPostgresRateLimitStore(s.pgContainer.Pool)
+	second := NewPostgresRateLimitStore(s.pgContainer.Pool)
+
+	result, err := first.Take(s.ctx, "key:1|game", policy, now)
+	s.Require().NoError(err)
+	s.True(result.Allowed)
+	s.Equal(1, result.Remaining)
+
+	result, err = second.Take(s.ctx, "key:1|game", policy, now)
+	s.Require().NoError(err)
+	s.True(result.Allowed)
+	s.Equal(0, result.Remaining)
+
+	result, err = first.Take(s.ctx, "key:1|game", policy, now)
+	s.Require().NoError(err)
+	s.False(result.Allowed)
+
+	result, err = second.Take(s.ctx, "key:1|game", policy, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.True(result.Allowed, "the bucket refills with the next window")
+	s.Equal(now.Add(2*time.Minute), result.Reset)
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestSignatureStoreClaimsOnce() {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	// Two instances race for the same signature
+	first := NewPostgresSignatureStore(s.pgContainer.Pool)
+	second := NewPostgresSignatureStore(s.pgContainer.Pool)
+
+	claimed, err := first.Claim(s.ctx, "game:sha256=abc", expiresAt)
+	s.Require().NoError(err)
+	s.True(claimed)
+
+	claimed, err = second.Claim(s.ctx, "game:sha256=abc", expiresAt)
+	s.Require().NoError(err)
+	s.False(claimed, "a signature is only claimed once")
+
+	s.Require().NoError(first.Release(s.ctx, "game:sha256=abc"))
+	claimed, err = second.Claim(s.ctx, "game:sha256=abc", expiresAt)
+	s.Require().NoError(err)
+	s.True(claimed, "a released signature may be claimed again")
+}
+
+func (s *PostgresTransactionRepositoryTestSuite) TestSchemaVersionMatchesMigrations() {
+	latest, err := migrations.Latest()
+	s.Require().NoError(err)
+
+	version, dirty, err := NewPostgresSchema(s.pgContainer.Pool).Version(s.ctx)
+	s.Require().NoError(err)
+	s.False(dirty)
+	s.Equal(latest, version)
+}
+
 func (s *PostgresTransactionRepositoryTestSuite) TestTransactionPropertyBased() {
 	parameters := gopter.DefaultTestParameters()
 	parameters.MinSuccessfulTests = 100
@@ -226,12 +1008,14 @@ func (s *PostgresTransactionRepositoryTestSuite) TestTransactionPropertyBased()
 	properties.Property("Transaction integrity and consistency", prop.ForAll(
 		func(transactions []*transaction.Transaction) bool {
 			s.SetupTest()
+			// Transaction IDs are unique per provider
 			processedTransactions := make(map[string]bool)
 
 			for i, tx := range transactions {
 				err := s.repo.Create(s.ctx, tx)
 
-				if processedTransactions[tx.TransactionID] {
+				key := string(tx.SourceType) + ":" + tx.TransactionID
+				if processedTransactions[key] {
 					if !errors.Is(err, internal.ErrDuplicateTransaction) {
 						fmt.Printf("Expected duplicate transaction error for transaction %d: %v\n", i, tx)
 						return false
@@ -244,10 +1028,10 @@ func (s *PostgresTransactionRepositoryTestSuite) TestTransactionPropertyBased()
 					return false
 				}
 
-				processedTransactions[tx.TransactionID] = true
+				processedTransactions[key] = true
 
 				// Verify the transaction was stored correctly
-				storedTx, err := s.repo.GetByID(s.ctx, tx.TransactionID)
+				storedTx, err := s.repo.GetByID(s.ctx, tx.SourceType, tx.TransactionID)
 				if err != nil {
 					fmt.Printf("Error retrieving transaction %d: %v\n", i, err)
 					return false
@@ -268,14 +1052,14 @@ func genTransaction() gopter.Gen {
 		gen.Identifier(),
 		gen.OneConstOf(transaction.SourceTypeGame, transaction.SourceTypeServer, transaction.SourceTypePayment),
 		gen.OneConstOf(transaction.StateWin, transaction.StateLost),
-		gen.Float64Range(0.01, 1000.00),
+		gen.Int64Range(1, 100000000),
 	).Map(func(v []interface{}) *transaction.Transaction {
 		tx := &transaction.Transaction{
 			TransactionID: v[0].(string),
 			AccountID:     1,
 			SourceType:    v[1].(transaction.SourceType),
 			State:         v[2].(transaction.State),
-			Amount:        v[3].(float64),
+			Amount:        money.FromUnits(v[3].(int64)),
 		}
 		tx.ProcessedAt = time.Now() // Ensure ProcessedAt is set
 		return tx
@@ -4,88 +4,133 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgconn"
 
 	"github.com/jackc/pgx/v4"
-	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/blackcloro/transaction-processor/internal"
+	"github.com/blackcloro/transaction-processor/internal/domain/ledger"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
 )
 
 type PostgresTransactionRepository struct {
-	db *pgxpool.Pool
+	db Querier
 }
 
-func NewPostgresTransactionRepository(db *pgxpool.Pool) *PostgresTransactionRepository {
+func NewPostgresTransactionRepository(db Querier) *PostgresTransactionRepository {
 	return &PostgresTransactionRepository{db: db}
 }
 
 func (r *PostgresTransactionRepository) Create(ctx context.Context, tx *transaction.Transaction) error {
-	_, err := r.db.Exec(ctx, `
-		INSERT INTO transactions (transaction_id, account_id, source_type, state, amount, processed_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, tx.TransactionID, tx.AccountID, tx.SourceType, tx.State, tx.Amount, tx.ProcessedAt)
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO transactions (transaction_id, account_id, source_type, state, amount, processed_at, balance_after, reverses_id, round_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, tx.TransactionID, tx.AccountID, tx.SourceType, tx.State, tx.Amount, tx.ProcessedAt, tx.BalanceAfter, tx.Reverses, roundID(tx)).Scan(&tx.ID)
+	return insertError(err)
+}
+
+func (r *PostgresTransactionRepository) CreateMany(ctx context.Context, txs []*transaction.Transaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	type key struct {
+		sourceType    transaction.SourceType
+		transactionID string
+	}
+	byKey := make(map[key]*transaction.Transaction, len(txs))
+	values := make([]string, len(txs))
+	args := make([]interface{}, 0, len(txs)*9)
+	for i, tx := range txs {
+		byKey[key{tx.SourceType, tx.TransactionID}] = tx
+		values[i] = placeholders(len(args), 9)
+		args = append(args, tx.TransactionID, tx.AccountID, tx.SourceType, tx.State, tx.Amount, tx.ProcessedAt, tx.BalanceAfter, tx.Reverses, roundID(tx))
+	}
+
+	rows, err := r.db.Query(ctx, `
+		INSERT INTO transactions (transaction_id, account_id, source_type, state, amount, processed_at, balance_after, reverses_id, round_id)
+		VALUES `+strings.Join(values, ", ")+`
+		RETURNING id, source_type, transaction_id
+	`, args...)
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			return internal.ErrDuplicateTransaction
-		} else if errors.As(err, &pgErr) && pgErr.Code == "22003" {
-			return internal.ErrNumericOverflow
+		return insertError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var k key
+		if err := rows.Scan(&id, &k.sourceType, &k.transactionID); err != nil {
+			return err
 		}
-		return err
+		byKey[k].ID = id
 	}
-	return nil
+	return insertError(rows.Err())
 }
 
-func (r *PostgresTransactionRepository) GetByID(ctx context.Context, id string) (*transaction.Transaction, error) {
-	var tx transaction.Transaction
-	err := r.db.QueryRow(ctx, `
-		SELECT id, transaction_id, account_id, source_type, state, amount, is_canceled, processed_at
-		FROM transactions
-		WHERE transaction_id = $1
-	`, id).Scan(
-		&tx.ID, &tx.TransactionID, &tx.AccountID, &tx.SourceType, &tx.State, &tx.Amount, &tx.IsCanceled, &tx.ProcessedAt,
+// roundID stores transactions outside of a round with a NULL round_id, which
+// the foreign key to rounds ignores.
+func roundID(tx *transaction.Transaction) *string {
+	if tx.RoundID == "" {
+		return nil
+	}
+	return &tx.RoundID
+}
+
+// insertError translates the constraint violations of an insert.
+func insertError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return internal.ErrDuplicateTransaction
+	} else if errors.As(err, &pgErr) && pgErr.Code == "22003" {
+		return internal.ErrNumericOverflow
+	}
+	return err
+}
+
+// transactionColumns selects a transaction aliased t, together with the
+// rollback linked to it, in the order scanTransaction reads them.
+const transactionColumns = `t.id, t.transaction_id, t.account_id, t.source_type, t.state, t.amount, t.is_canceled,
+	       t.processed_at, t.balance_after, t.reverses_id, COALESCE(t.round_id, ''),
+	       COALESCE((SELECT o.transaction_id FROM transactions o WHERE o.id = t.reverses_id), ''),
+	       (SELECT r.id FROM transactions r WHERE r.reverses_id = t.id)`
+
+func scanTransaction(row pgx.Row) (*transaction.Transaction, error) {
+	tx := &transaction.Transaction{}
+	err := row.Scan(
+		&tx.ID, &tx.TransactionID, &tx.AccountID, &tx.SourceType, &tx.State, &tx.Amount, &tx.IsCanceled,
+		&tx.ProcessedAt, &tx.BalanceAfter, &tx.Reverses, &tx.RoundID, &tx.OriginalTransactionID, &tx.ReversedBy,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (r *PostgresTransactionRepository) GetByID(ctx context.Context, sourceType transaction.SourceType, id string) (*transaction.Transaction, error) {
+	tx, err := scanTransaction(r.db.QueryRow(ctx, `
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		WHERE t.source_type = $1 AND t.transaction_id = $2
+	`, sourceType, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, internal.ErrTransactionNotFound
 		}
 		return nil, err
 	}
-	return &tx, nil
+	return tx, nil
 }
 
-func (r *PostgresTransactionRepository) GetLatestOddRecords(ctx context.Context, limit int) ([]*transaction.Transaction, error) {
+func (r *PostgresTransactionRepository) GetByIDs(ctx context.Context, sourceType transaction.SourceType, ids []string) ([]*transaction.Transaction, error) {
 	rows, err := r.db.Query(ctx, `
-		WITH ranked_transactions AS (
-			SELECT 
-				id, 
-				transaction_id, 
-				account_id, 
-				source_type, 
-				state, 
-				amount, 
-				is_canceled, 
-				processed_at,
-				ROW_NUMBER() OVER (ORDER BY processed_at DESC) AS row_num
-			FROM transactions
-			WHERE is_canceled = false
-		)
-		SELECT 
-			id, 
-			transaction_id, 
-			account_id, 
-			source_type, 
-			state, 
-			amount, 
-			is_canceled, 
-			processed_at
-		FROM ranked_transactions
-		WHERE row_num % 2 = 1
-		LIMIT $1
-	`, limit)
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		WHERE t.source_type = $1 AND t.transaction_id = ANY($2)
+	`, sourceType, ids)
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +138,71 @@ func (r *PostgresTransactionRepository) GetLatestOddRecords(ctx context.Context,
 
 	var transactions []*transaction.Transaction
 	for rows.Next() {
-		tx := &transaction.Transaction{}
-		err := rows.Scan(
-			&tx.ID, &tx.TransactionID, &tx.AccountID, &tx.SourceType, &tx.State, &tx.Amount, &tx.IsCanceled, &tx.ProcessedAt,
-		)
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, rows.Err()
+}
+
+func (r *PostgresTransactionRepository) List(ctx context.Context, filter transaction.Filter) ([]*transaction.Transaction, error) {
+	conditions := []string{"t.account_id = $1"}
+	args := []interface{}{filter.AccountID}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.SourceType != nil {
+		where("t.source_type = $%d", *filter.SourceType)
+	}
+	if filter.State != nil {
+		where("t.state = $%d", *filter.State)
+	}
+	if filter.IsCanceled != nil {
+		where("t.is_canceled = $%d", *filter.IsCanceled)
+	}
+	if filter.RoundID != nil {
+		where("t.round_id = $%d", *filter.RoundID)
+	}
+	if filter.ExcludeRollbacks {
+		conditions = append(conditions, "t.state <> 'rollback'")
+	}
+	if filter.MinAmount != nil {
+		where("t.amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		where("t.amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		where("t.processed_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("t.processed_at < $%d", *filter.To)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.ProcessedAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(t.processed_at, t.id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`
+		SELECT `+transactionColumns+`
+		FROM transactions t
+		WHERE %s
+		ORDER BY t.processed_at DESC, t.id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*transaction.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -110,48 +216,173 @@ func (r *PostgresTransactionRepository) GetLatestOddRecords(ctx context.Context,
 	return transactions, nil
 }
 
-func (r *PostgresTransactionRepository) MarkAsCanceled(ctx context.Context, ids []string) error {
+func (r *PostgresTransactionRepository) ListAccountIDs(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT account_id
+		FROM transactions
+		WHERE is_canceled = false
+		ORDER BY account_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accountIDs []int64
+	for rows.Next() {
+		var accountID int64
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accountIDs, nil
+}
+
+func (r *PostgresTransactionRepository) Cancel(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE transactions
+		SET is_canceled = true,
+		    canceled_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND is_canceled = false
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return internal.ErrAlreadyReversed
+	}
+	return nil
+}
+
+func (r *PostgresTransactionRepository) MarkAsCanceled(ctx context.Context, accountID int64, ids []int64, policy transaction.OverdraftPolicy) (*transaction.CancellationResult, error) {
 	// Start a transaction
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx) // Rollback in case of error
 
-	// Mark transactions as canceled
-	_, err = tx.Exec(ctx, `
-        UPDATE transactions
-        SET is_canceled = true
-        WHERE transaction_id = ANY($1)
-    `, ids)
+	accountRepo := NewPostgresAccountRepository(tx)
+	acc, err := accountRepo.GetByIDForUpdate(ctx, accountID)
 	if err != nil {
-		return fmt.Errorf("failed to mark transactions as canceled: %w", err)
+		return nil, fmt.Errorf("failed to lock account: %w", err)
 	}
 
-	// Update account balance
-	_, err = tx.Exec(ctx, `
-        UPDATE account
-        SET balance = balance - COALESCE(
-            (SELECT SUM(
-                CASE 
-                    WHEN state = 'win' THEN amount 
-                    WHEN state = 'lost' THEN -amount
-                END
-            )
-            FROM transactions
-            WHERE transaction_id = ANY($1) AND is_canceled = true),
-            0
-        )
-        WHERE id = 1
-    `, ids)
+	// Lock the rows too, so a concurrent run cannot cancel them in between
+	rows, err := tx.Query(ctx, `
+		SELECT id, transaction_id, account_id, source_type, state, amount, is_canceled
+		FROM transactions
+		WHERE account_id = $1 AND id = ANY($2) AND state <> 'rollback'
+		ORDER BY id
+		FOR UPDATE
+	`, accountID, ids)
 	if err != nil {
-		return fmt.Errorf("failed to update account balance: %w", err)
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+	var candidates []*transaction.Transaction
+	for rows.Next() {
+		t := &transaction.Transaction{}
+		if err := rows.Scan(&t.ID, &t.TransactionID, &t.AccountID, &t.SourceType, &t.State, &t.Amount, &t.IsCanceled); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to load transactions: %w", err)
+		}
+		candidates = append(candidates, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load transactions: %w", err)
+	}
+
+	result := &transaction.CancellationResult{
+		AccountID:       accountID,
+		Policy:          policy,
+		Canceled:        []transaction.CanceledTransaction{},
+		AlreadyCanceled: []int64{},
+		Skipped:         []int64{},
+		NotFound:        []int64{},
+	}
+
+	found := make(map[int64]bool, len(candidates))
+	ledgerRepo := NewPostgresLedgerRepository(tx)
+	for _, t := range candidates {
+		found[t.ID] = true
+		if t.IsCanceled {
+			result.AlreadyCanceled = append(result.AlreadyCanceled, t.ID)
+			continue
+		}
+
+		reversed, shortfall, err := acc.ReverseTransaction(t, policy)
+		if errors.Is(err, internal.ErrInsufficientFunds) {
+			result.Skipped = append(result.Skipped, t.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE transactions
+			SET is_canceled = true,
+			    canceled_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark transactions as canceled: %w", err)
+		}
+
+		if !reversed.IsZero() {
+			entry := ledger.NewEntry(accountID, &t.ID, ledger.ReasonCancel, reversed)
+			if err := ledgerRepo.Append(ctx, &entry); err != nil {
+				return nil, fmt.Errorf("failed to post cancellation: %w", err)
+			}
+		}
+
+		if !shortfall.IsZero() {
+			recoverable := policy == transaction.OverdraftClamp
+			_, err = tx.Exec(ctx, `
+				INSERT INTO account_debts (account_id, transaction_ref, amount, recoverable)
+				VALUES ($1, $2, $3, $4)
+			`, accountID, t.ID, shortfall, recoverable)
+			if err != nil {
+				return nil, fmt.Errorf("failed to record debt: %w", err)
+			}
+			if recoverable {
+				result.Debt = result.Debt.Add(shortfall)
+			}
+		}
+
+		result.Canceled = append(result.Canceled, transaction.CanceledTransaction{
+			ID:            t.ID,
+			TransactionID: t.TransactionID,
+			SourceType:    t.SourceType,
+			Reversed:      reversed,
+			Shortfall:     shortfall,
+		})
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	// Update account balance
+	if len(result.Canceled) > 0 {
+		if err := accountRepo.Update(ctx, acc); err != nil {
+			return nil, fmt.Errorf("failed to update account balance: %w", err)
+		}
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
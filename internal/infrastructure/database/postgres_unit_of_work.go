@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/processing"
+)
+
+type PostgresUnitOfWork struct {
+	db Querier
+}
+
+func NewPostgresUnitOfWork(db Querier) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos processing.Repositories) error) error {
+	tx, err := u.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // Rollback in case of error
+
+	repos := processing.Repositories{
+		Accounts:     NewPostgresAccountRepository(tx),
+		Transactions: NewPostgresTransactionRepository(tx),
+		Ledger:       NewPostgresLedgerRepository(tx),
+		Rounds:       NewPostgresRoundRepository(tx),
+	}
+	if err := fn(ctx, repos); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/blackcloro/transaction-processor/internal/tracing"
+)
+
+var tracer = otel.Tracer("github.com/blackcloro/transaction-processor/internal/infrastructure/database")
+
+// TracingQuerier wraps a Querier and records a client span for every SQL
+// statement, including the statements of transactions begun through it.
+type TracingQuerier struct {
+	next Querier
+}
+
+func NewTracingQuerier(next Querier) *TracingQuerier {
+	return &TracingQuerier{next: next}
+}
+
+func (q *TracingQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	ctx, span := startSpan(ctx, "BEGIN")
+	tx, err := q.next.Begin(ctx)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingTx{Tx: tx}, nil
+}
+
+func (q *TracingQuerier) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startSpan(ctx, sql)
+	tag, err := q.next.Exec(ctx, sql, arguments...)
+	tracing.End(span, err)
+	return tag, err
+}
+
+func (q *TracingQuerier) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startSpan(ctx, sql)
+	rows, err := q.next.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	return &tracingRows{Rows: rows, span: span}, nil
+}
+
+func (q *TracingQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startSpan(ctx, sql)
+	return &tracingRow{row: q.next.QueryRow(ctx, sql, args...), span: span}
+}
+
+// tracingTx traces the statements run inside a transaction.
+type tracingTx struct {
+	pgx.Tx
+}
+
+func (t *tracingTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return NewTracingQuerier(t.Tx).Begin(ctx)
+}
+
+func (t *tracingTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return NewTracingQuerier(t.Tx).Exec(ctx, sql, arguments...)
+}
+
+func (t *tracingTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return NewTracingQuerier(t.Tx).Query(ctx, sql, args...)
+}
+
+func (t *tracingTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return NewTracingQuerier(t.Tx).QueryRow(ctx, sql, args...)
+}
+
+func (t *tracingTx) Commit(ctx context.Context) error {
+	_, span := startSpan(ctx, "COMMIT")
+	err := t.Tx.Commit(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// tracingRows ends the statement's span once the rows are closed.
+type tracingRows struct {
+	pgx.Rows
+	span trace.Span
+}
+
+func (r *tracingRows) Close() {
+	r.Rows.Close()
+	if r.span != nil {
+		tracing.End(r.span, r.Rows.Err())
+		r.span = nil
+	}
+}
+
+// tracingRow ends the statement's span once the row is scanned.
+type tracingRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracingRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	// No rows is an answer, not a failure of the statement
+	if errors.Is(err, pgx.ErrNoRows) {
+		tracing.End(r.span, nil)
+	} else {
+		tracing.End(r.span, err)
+	}
+	return err
+}
+
+func startSpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	operation := "QUERY"
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	return tracer.Start(ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(operation),
+			semconv.DBStatement(strings.TrimSpace(sql)),
+		))
+}
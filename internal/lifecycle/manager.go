@@ -0,0 +1,116 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// ErrShutdownTimeout is returned by Run when components are still draining
+// once the shutdown timeout expires.
+var ErrShutdownTimeout = errors.New("shutdown timed out")
+
+// Component is a long-running part of the process, such as the HTTP server
+// or a worker.
+type Component struct {
+	Name string
+	// Run blocks until the component is stopped or fails.
+	Run func() error
+	// Stop drains the component. It must give up once ctx is done.
+	Stop func(ctx context.Context) error
+}
+
+// Manager starts components together and shuts them down together, closing
+// shared resources such as the database pool only once every component has
+// stopped.
+type Manager struct {
+	shutdownTimeout time.Duration
+	components      []Component
+	closers         []func()
+}
+
+func NewManager(shutdownTimeout time.Duration) *Manager {
+	return &Manager{shutdownTimeout: shutdownTimeout}
+}
+
+// Add registers a component to be started by Run.
+func (m *Manager) Add(c Component) {
+	m.components = append(m.components, c)
+}
+
+// OnClose registers fn to run after every component has stopped. Closers run
+// in reverse order of registration.
+func (m *Manager) OnClose(fn func()) {
+	m.closers = append(m.closers, fn)
+}
+
+// Run starts every component and blocks until ctx is done or a component
+// fails, then stops all of them and runs the closers. It returns the first
+// component failure, ErrShutdownTimeout if draining did not finish in time,
+// or nil after a clean shutdown.
+func (m *Manager) Run(ctx context.Context) error {
+	failed := make(chan error, len(m.components))
+	exited := make([]chan struct{}, len(m.components))
+	for i, c := range m.components {
+		exited[i] = make(chan struct{})
+		go func(c Component, exited chan struct{}) {
+			defer close(exited)
+			if err := c.Run(); err != nil {
+				failed <- fmt.Errorf("%s: %w", c.Name, err)
+			}
+		}(c, exited[i])
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutdown requested")
+	case runErr = <-failed:
+		logger.Error("Component failed, shutting down", runErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	stopErr := m.stop(shutdownCtx, exited)
+
+	for i := len(m.closers) - 1; i >= 0; i-- {
+		m.closers[i]()
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+	return stopErr
+}
+
+// stop drains every component concurrently and waits for their Run to
+// return, giving up at the deadline of ctx.
+func (m *Manager) stop(ctx context.Context, exited []chan struct{}) error {
+	var wg sync.WaitGroup
+	for i, c := range m.components {
+		wg.Add(1)
+		go func(c Component, exited chan struct{}) {
+			defer wg.Done()
+			if err := c.Stop(ctx); err != nil {
+				logger.Error("Failed to stop component", err, "component", c.Name)
+			}
+			select {
+			case <-exited:
+				logger.Info("Component stopped", "component", c.Name)
+			case <-ctx.Done():
+				logger.Warn("Component did not stop in time", "component", c.Name)
+			}
+		}(c, exited[i])
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ErrShutdownTimeout
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/account"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// collectTimeout bounds the queries run while serving a scrape.
+const collectTimeout = 5 * time.Second
+
+// AccountLister lists the accounts whose balances are exported.
+type AccountLister interface {
+	ListAccounts(ctx context.Context) ([]*account.Account, error)
+}
+
+// balanceCollector reads the account balances at scrape time, so the gauge
+// also reflects changes made by other instances and by the workers.
+type balanceCollector struct {
+	accounts AccountLister
+	balance  *prometheus.Desc
+}
+
+// RegisterBalances exports the current balance of every account.
+func RegisterBalances(accounts AccountLister) {
+	prometheus.MustRegister(&balanceCollector{
+		accounts: accounts,
+		balance: prometheus.NewDesc(namespace+"_account_balance",
+			"Current balance per account.", []string{"account_id"}, nil),
+	})
+}
+
+func (c *balanceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.balance
+}
+
+func (c *balanceCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	accounts, err := c.accounts.ListAccounts(ctx)
+	if err != nil {
+		logger.Error("Failed to collect account balances", err)
+		ch <- prometheus.NewInvalidMetric(c.balance, err)
+		return
+	}
+	for _, a := range accounts {
+		ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue,
+			amountValue(a.Balance), strconv.FormatInt(a.ID, 10))
+	}
+}
+
+// poolCollector exports the connection pool statistics.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	emptyAcquire *prometheus.Desc
+	acquireWait  *prometheus.Desc
+}
+
+// RegisterPool exports the statistics of the database connection pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(namespace+"_db_pool_"+name, help, nil, nil)
+	}
+	prometheus.MustRegister(&poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Idle connections."),
+		total:        desc("total_connections", "Open connections."),
+		max:          desc("max_connections", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquire: desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		acquireWait:  desc("acquire_wait_seconds_total", "Time spent acquiring connections."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.emptyAcquire
+	ch <- c.acquireWait
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
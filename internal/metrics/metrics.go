@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
+)
+
+const namespace = "transaction_processor"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	transactionsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_processed_total",
+		Help:      "Transactions applied to an account balance by source type and state.",
+	}, []string{"source_type", "state"})
+
+	transactionsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_rejected_total",
+		Help:      "Transactions rejected by source type and reason.",
+	}, []string{"source_type", "reason"})
+
+	transactionsReplayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_replayed_total",
+		Help:      "Resubmitted transactions answered with their original result, by source type.",
+	}, []string{"source_type"})
+
+	workerRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_runs_total",
+		Help:      "Worker runs by worker and result (success or failure).",
+	}, []string{"worker", "result"})
+
+	workerRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "worker_run_duration_seconds",
+		Help:      "Duration of worker runs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"worker"})
+
+	transactionsCanceled = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_transactions_canceled_total",
+		Help:      "Transactions canceled by post-processing.",
+	})
+
+	workerLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_leader",
+		Help:      "1 while this instance holds the worker's leader lock, 0 otherwise.",
+	}, []string{"worker"})
+
+	workerLockEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_lock_events_total",
+		Help:      "Leader lock events by worker and event (acquired, skipped or lost).",
+	}, []string{"worker", "event"})
+)
+
+// Rejection reasons of transactions.
+const (
+	ReasonDuplicate         = "duplicate"
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonAlreadyReversed   = "already_reversed"
+)
+
+func ObserveHTTPRequest(method, route, status string, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+func TransactionProcessed(sourceType, state string) {
+	transactionsProcessed.WithLabelValues(sourceType, state).Inc()
+}
+
+func TransactionRejected(sourceType, reason string) {
+	transactionsRejected.WithLabelValues(sourceType, reason).Inc()
+}
+
+func TransactionReplayed(sourceType string) {
+	transactionsReplayed.WithLabelValues(sourceType).Inc()
+}
+
+// ObserveWorkerRun records one run of the named worker.
+func ObserveWorkerRun(worker string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	workerRuns.WithLabelValues(worker, result).Inc()
+	workerRunDuration.WithLabelValues(worker).Observe(duration.Seconds())
+}
+
+func TransactionsCanceled(n int) {
+	transactionsCanceled.Add(float64(n))
+}
+
+// SetLeader records whether this instance holds the named worker's leader
+// lock.
+func SetLeader(worker string, leader bool) {
+	value := 0.0
+	if leader {
+		value = 1
+	}
+	workerLeader.WithLabelValues(worker).Set(value)
+}
+
+func LockEvent(worker, event string) {
+	workerLockEvents.WithLabelValues(worker, event).Inc()
+}
+
+// amountValue converts an amount for a gauge; metrics tolerate the rounding.
+func amountValue(a money.Amount) float64 {
+	return float64(a.Units()) / math.Pow10(money.Scale)
+}
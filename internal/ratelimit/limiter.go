@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result is the state of a bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the current window ends and the bucket refills.
+	Reset time.Time
+}
+
+// Store counts requests in fixed windows. Stores shared by several instances
+// enforce the limits across all of them.
+type Store interface {
+	// Take counts one request against key in the window of policy containing
+	// now.
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// Policies selects the policy of a request: the one of its API key, else the
+// one of its source type, else the default.
+type Policies struct {
+	// Client limits every request of a client IP, counted before the caller
+	// is known.
+	Client      Policy
+	Default     Policy
+	SourceTypes map[string]Policy
+	// APIKeys are keyed by API key name.
+	APIKeys map[string]Policy
+}
+
+func (p Policies) forSubject(s Subject) Policy {
+	if policy, ok := p.APIKeys[s.APIKey]; ok && s.APIKey != "" {
+		return policy
+	}
+	if policy, ok := p.SourceTypes[s.SourceType]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// Subject identifies who a request is counted against.
+type Subject struct {
+	// Identity is the caller, e.g. its API key ID or, without one, its IP.
+	Identity string
+	// APIKey is the name of the caller's API key, if it sent one.
+	APIKey     string
+	SourceType string
+}
+
+// Limiter counts each caller's requests per source type, so one provider's
+// traffic never eats into another's.
+type Limiter struct {
+	store    Store
+	policies Policies
+	now      func() time.Time
+}
+
+func NewLimiter(store Store, policies Policies) *Limiter {
+	return &Limiter{store: store, policies: policies, now: time.Now}
+}
+
+// TakeClient counts a request of the client at ip against the client policy,
+// whoever the caller turns out to be, and reports whether it is allowed.
+func (l *Limiter) TakeClient(ctx context.Context, ip string) (Result, error) {
+	return l.store.Take(ctx, "client:"+ip, l.policies.Client, l.now())
+}
+
+// Take counts a request of s and reports whether it is allowed.
+func (l *Limiter) Take(ctx context.Context, s Subject) (Result, error) {
+	return l.store.Take(ctx, s.Identity+"|"+s.SourceType, l.policies.forSubject(s), l.now())
+}
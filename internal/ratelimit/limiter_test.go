@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(NewMemoryStore(), Policies{
+		Default:     Policy{Limit: 5, Window: time.Minute},
+		SourceTypes: map[string]Policy{"payment": {Limit: 1, Window: time.Minute}},
+		APIKeys:     map[string]Policy{"bulk": {Limit: 3, Window: time.Minute}},
+	})
+	limiter.now = func() time.Time { return now }
+
+	take := func(s Subject) Result {
+		result, err := limiter.Take(context.Background(), s)
+		require.NoError(t, err)
+		return result
+	}
+
+	payment := Subject{Identity: "key:1", SourceType: "payment"}
+	assert.True(t, take(payment).Allowed)
+	assert.False(t, take(payment).Allowed, "the payment policy allows one request per minute")
+
+	// Each source type has a bucket of its own
+	game := Subject{Identity: "key:1", SourceType: "game"}
+	result := take(game)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 5, result.Limit)
+	assert.Equal(t, 4, result.Remaining)
+	assert.Equal(t, now.Add(time.Minute), result.Reset)
+
+	// The key's own policy wins over the source type's
+	bulk := Subject{Identity: "key:2", APIKey: "bulk", SourceType: "payment"}
+	for range 3 {
+		assert.True(t, take(bulk).Allowed)
+	}
+	assert.False(t, take(bulk).Allowed)
+
+	now = now.Add(time.Minute)
+	assert.True(t, take(payment).Allowed, "the bucket refills with the next window")
+}
+
+func TestLimiterTakeClient(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), Policies{
+		Client:  Policy{Limit: 1, Window: time.Minute},
+		Default: Policy{Limit: 5, Window: time.Minute},
+	})
+
+	result, err := limiter.TakeClient(context.Background(), "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Limit)
+
+	result, err = limiter.TakeClient(context.Background(), "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// The client bucket is apart from the caller's own
+	result, err = limiter.Take(context.Background(), Subject{Identity: "ip:10.0.0.1"})
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies("game=1000/1m, payment=100/30s")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Policy{
+		"game":    {Limit: 1000, Window: time.Minute},
+		"payment": {Limit: 100, Window: 30 * time.Second},
+	}, policies)
+
+	for _, invalid := range []string{"game", "game=100", "game=0/1m", "game=10/1ms", "=10/1m"} {
+		_, err := ParsePolicies(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often expired windows are dropped from memory.
+const pruneInterval = time.Minute
+
+// MemoryStore keeps the counters in process, so every instance enforces the
+// limits on its own.
+type MemoryStore struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	pruned  time.Time
+}
+
+type window struct {
+	start time.Time
+	end   time.Time
+	count int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]*window)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.pruned) > pruneInterval {
+		for k, w := range s.windows {
+			if !now.Before(w.end) {
+				delete(s.windows, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	start := now.Truncate(policy.Window)
+	w, ok := s.windows[key]
+	if !ok || !w.start.Equal(start) {
+		w = &window{start: start, end: start.Add(policy.Window)}
+		s.windows[key] = w
+	}
+	w.count++
+
+	return result(policy, w.count, w.end), nil
+}
+
+func result(policy Policy, count int, reset time.Time) Result {
+	return Result{
+		Allowed:   count <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: max(policy.Limit-count, 0),
+		Reset:     reset,
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy allows Limit requests per Window.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s", p.Limit, p.Window)
+}
+
+// ParsePolicy reads a policy written as limit/window, e.g. 600/1m.
+func ParsePolicy(s string) (Policy, error) {
+	rawLimit, rawWindow, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit %q, expected limit/window such as 600/1m", s)
+	}
+	limit, err := strconv.Atoi(rawLimit)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q, the limit must be a positive number", s)
+	}
+	window, err := time.ParseDuration(rawWindow)
+	if err != nil || window < time.Second {
+		return Policy{}, fmt.Errorf("invalid rate limit %q, the window must be a duration of at least 1s", s)
+	}
+	return Policy{Limit: limit, Window: window}, nil
+}
+
+// ParsePolicies reads comma separated name=limit/window pairs, e.g.
+// game=1000/1m,payment=100/1m.
+func ParsePolicies(s string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, rawPolicy, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid rate limit %q, expected name=limit/window", pair)
+		}
+		policy, err := ParsePolicy(rawPolicy)
+		if err != nil {
+			return nil, err
+		}
+		policies[strings.TrimSpace(name)] = policy
+	}
+	return policies, nil
+}
@@ -0,0 +1,48 @@
+package signing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the signatures in process, so every instance rejects
+// replays on its own and forgets them on restart.
+type MemoryStore struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{seen: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, key string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.pruned) > time.Minute {
+		for k, expires := range s.seen {
+			if !now.Before(expires) {
+				delete(s.seen, k)
+			}
+		}
+		s.pruned = now
+	}
+
+	if _, ok := s.seen[key]; ok {
+		return false, nil
+	}
+	s.seen[key] = expiresAt
+	return true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.seen, key)
+	return nil
+}
@@ -0,0 +1,130 @@
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+// signaturePrefix names the algorithm in the signature header value.
+const signaturePrefix = "sha256="
+
+// Store keeps the signatures of accepted requests until they expire. Stores
+// shared by several instances reject replays across all of them.
+type Store interface {
+	// Claim records key until expiresAt unless it is already recorded, and
+	// reports whether it did. Of concurrent claims of one key, only one wins.
+	Claim(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+	// Release forgets key, so the request may be sent again.
+	Release(ctx context.Context, key string) error
+}
+
+// Verifier checks that a request was signed by its provider with the
+// provider's shared secret. The signature is the hex HMAC-SHA256 of the
+// request's timestamp, method, path, Account-ID header and body, so none can
+// be altered, and a request is only accepted within tolerance of its
+// timestamp and once.
+type Verifier struct {
+	secrets   map[string][]byte
+	tolerance time.Duration
+	store     Store
+	now       func() time.Time
+}
+
+// NewVerifier returns a verifier for the providers in secrets, keyed by
+// provider name, that records accepted requests in store.
+func NewVerifier(secrets map[string]string, tolerance time.Duration, store Store) *Verifier {
+	keys := make(map[string][]byte, len(secrets))
+	for provider, secret := range secrets {
+		keys[provider] = []byte(secret)
+	}
+	return &Verifier{
+		secrets:   keys,
+		tolerance: tolerance,
+		store:     store,
+		now:       time.Now,
+	}
+}
+
+// Request is the part of a request covered by its signature.
+type Request struct {
+	// Timestamp is when the request was sent, a unix time in seconds.
+	Timestamp string
+	Method    string
+	Path      string
+	// AccountID is the Account-ID header, empty on routes naming the account
+	// in the path.
+	AccountID string
+	Body      []byte
+}
+
+// Sign returns the signature header value for r: the HMAC of its timestamp,
+// method, path and Account-ID header, each followed by a newline, and its
+// body.
+func Sign(secret string, r Request) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range []string{r.Timestamp, r.Method, r.Path, r.AccountID} {
+		mac.Write([]byte(part))
+		mac.Write([]byte("\n"))
+	}
+	mac.Write(r.Body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of r sent by provider, and claims the signature
+// so the request is not accepted again. Callers Release the claim if they
+// fail to handle the request.
+func (v *Verifier) Verify(ctx context.Context, provider, signature string, r Request) error {
+	if signature == "" {
+		return internal.ErrMissingSignature
+	}
+	if r.Timestamp == "" {
+		return internal.ErrMissingTimestamp
+	}
+	seconds, err := strconv.ParseInt(r.Timestamp, 10, 64)
+	if err != nil {
+		return internal.ErrInvalidTimestamp
+	}
+
+	now := v.now()
+	sentAt := time.Unix(seconds, 0)
+	if sentAt.Before(now.Add(-v.tolerance)) || sentAt.After(now.Add(v.tolerance)) {
+		return internal.ErrStaleTimestamp
+	}
+
+	secret, ok := v.secrets[provider]
+	if !ok {
+		return internal.ErrUnknownProvider
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return internal.ErrInvalidSignature
+	}
+	expected := Sign(string(secret), r)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return internal.ErrInvalidSignature
+	}
+
+	// Signatures are only kept as long as their timestamp could pass the
+	// window check, after which they are rejected as stale anyway
+	claimed, err := v.store.Claim(ctx, provider+":"+signature, now.Add(2*v.tolerance))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return internal.ErrReplayedRequest
+	}
+	return nil
+}
+
+// Release gives up the claim Verify took on the request provider signed with
+// signature, so it may be sent again.
+func (v *Verifier) Release(ctx context.Context, provider, signature string) error {
+	return v.store.Release(ctx, provider+":"+signature)
+}
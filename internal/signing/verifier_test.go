@@ -0,0 +1,108 @@
+package signing
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/blackcloro/transaction-processor/internal"
+)
+
+// request returns a submission sent at timestamp.
+func request(timestamp string) Request {
+	return Request{
+		Timestamp: timestamp,
+		Method:    "POST",
+		Path:      "/api/v1/transactions",
+		AccountID: "1",
+		Body:      []byte(`{"state":"win","amount":"10","transactionId":"t1"}`),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	valid := request(timestamp)
+	signature := Sign("game-secret", valid)
+
+	with := func(change func(r *Request)) Request {
+		r := request(timestamp)
+		change(&r)
+		return r
+	}
+
+	tests := []struct {
+		name      string
+		provider  string
+		signature string
+		request   Request
+		want      error
+	}{
+		{"valid", "game", signature, valid, nil},
+		{"missing signature", "game", "", valid, internal.ErrMissingSignature},
+		{"missing timestamp", "game", signature, with(func(r *Request) { r.Timestamp = "" }), internal.ErrMissingTimestamp},
+		{"malformed timestamp", "game", Sign("game-secret", request("yesterday")), request("yesterday"), internal.ErrInvalidTimestamp},
+		{"stale timestamp", "game", Sign("game-secret", request(stale)), request(stale), internal.ErrStaleTimestamp},
+		{"unknown provider", "payment", signature, valid, internal.ErrUnknownProvider},
+		{"other provider's secret", "game", Sign("server-secret", valid), valid, internal.ErrInvalidSignature},
+		{"tampered body", "game", signature, with(func(r *Request) { r.Body = []byte(`{"amount":"1000"}`) }), internal.ErrInvalidSignature},
+		{"other method", "game", signature, with(func(r *Request) { r.Method = "PUT" }), internal.ErrInvalidSignature},
+		{"other path", "game", signature, with(func(r *Request) { r.Path = "/api/v1/accounts/2/transactions" }), internal.ErrInvalidSignature},
+		{"other account", "game", signature, with(func(r *Request) { r.AccountID = "2" }), internal.ErrInvalidSignature},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := NewVerifier(map[string]string{"game": "game-secret", "server": "server-secret"}, 5*time.Minute, NewMemoryStore())
+			v.now = func() time.Time { return now }
+			assert.ErrorIs(t, v.Verify(context.Background(), tc.provider, tc.signature, tc.request), tc.want)
+		})
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	r := request(strconv.FormatInt(now.Unix(), 10))
+	signature := Sign("game-secret", r)
+
+	v := NewVerifier(map[string]string{"game": "game-secret"}, 5*time.Minute, NewMemoryStore())
+	v.now = func() time.Time { return now }
+
+	assert.NoError(t, v.Verify(ctx, "game", signature, r))
+	assert.ErrorIs(t, v.Verify(ctx, "game", signature, r), internal.ErrReplayedRequest)
+
+	assert.NoError(t, v.Release(ctx, "game", signature))
+	assert.NoError(t, v.Verify(ctx, "game", signature, r), "a released request may be retried")
+
+	now = now.Add(11 * time.Minute)
+	assert.ErrorIs(t, v.Verify(ctx, "game", signature, r), internal.ErrStaleTimestamp, "expired signatures are stale anyway")
+}
+
+func TestVerifyAcceptsConcurrentCopiesOnce(t *testing.T) {
+	ctx := context.Background()
+	r := request(strconv.FormatInt(time.Now().Unix(), 10))
+	signature := Sign("game-secret", r)
+	v := NewVerifier(map[string]string{"game": "game-secret"}, 5*time.Minute, NewMemoryStore())
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v.Verify(ctx, "game", signature, r) == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), accepted.Load())
+}
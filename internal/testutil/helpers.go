@@ -3,23 +3,23 @@ package testutil
 import (
 	"context"
 	"fmt"
-	"math"
 	"time"
 
+	"github.com/blackcloro/transaction-processor/internal/domain/money"
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/stretchr/testify/require"
 )
 
 // ResetAccountBalance sets the balance of the account with the given ID to the specified amount.
-func ResetAccountBalance(ctx context.Context, t require.TestingT, pool *pgxpool.Pool, accountID int, balance float64) {
+func ResetAccountBalance(ctx context.Context, t require.TestingT, pool *pgxpool.Pool, accountID int, balance money.Amount) {
 	_, err := pool.Exec(ctx, "UPDATE account SET balance = $1 WHERE id = $2", balance, accountID)
 	require.NoError(t, err)
 }
 
-// TruncateTransactions removes all records from the transactions table.
+// TruncateTransactions removes all records from the transactions and rounds tables and the ledger postings referencing them.
 func TruncateTransactions(ctx context.Context, t require.TestingT, pool *pgxpool.Pool) {
-	_, err := pool.Exec(ctx, "TRUNCATE TABLE transactions")
+	_, err := pool.Exec(ctx, "TRUNCATE TABLE transactions, rounds CASCADE")
 	require.NoError(t, err)
 }
 
@@ -31,7 +31,7 @@ func GenerateTransactions(count int) []transaction.Transaction {
 			AccountID:     1,
 			SourceType:    transaction.SourceTypeGame,
 			State:         transaction.StateWin,
-			Amount:        float64(i+1) * 10,
+			Amount:        money.FromInt(int64(i+1) * 10),
 		}
 	}
 	return txs
@@ -42,7 +42,7 @@ func CompareTransactions(original, stored *transaction.Transaction) bool {
 	if original.TransactionID != stored.TransactionID ||
 		original.SourceType != stored.SourceType ||
 		original.State != stored.State ||
-		math.Abs(original.Amount-stored.Amount) > 0.00001 { // Use a small epsilon for float comparison
+		original.Amount != stored.Amount {
 		fmt.Printf("Mismatch in transaction details:\nOriginal: %+v\nStored: %+v\n", original, stored)
 		return false
 	}
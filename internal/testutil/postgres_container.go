@@ -5,17 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"path/filepath"
-	"runtime"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
-
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/blackcloro/transaction-processor/migrations"
 )
 
 type PostgresContainer struct {
@@ -85,12 +82,6 @@ func NewPostgresContainer(ctx context.Context, config PostgresConfig) (*Postgres
 }
 
 func (pc *PostgresContainer) MigrateDB(ctx context.Context) error {
-	_, path, _, ok := runtime.Caller(0)
-	if !ok {
-		return fmt.Errorf("failed to get path")
-	}
-	pathToMigrationFiles := filepath.Dir(path) + "/../../migrations"
-
 	hostIP, err := pc.Container.Host(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get container host: %w", err)
@@ -104,7 +95,7 @@ func (pc *PostgresContainer) MigrateDB(ctx context.Context) error {
 	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		pc.Config.User, pc.Config.Password, hostIP, mappedPort.Port(), pc.Config.DBName)
 
-	m, err := migrate.New(fmt.Sprintf("file:%s", pathToMigrationFiles), databaseURL)
+	m, err := migrations.New(databaseURL)
 	if err != nil {
 		return err
 	}
@@ -2,57 +2,243 @@ package worker
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+	"github.com/blackcloro/transaction-processor/internal/metrics"
 	"github.com/blackcloro/transaction-processor/pkg/logger"
 )
 
+const postProcessingWorker = "post_processing"
+
+// Locker grants one instance at a time the right to run a job.
+type Locker interface {
+	// TryLock takes the lock without waiting. The returned channel is closed
+	// when the lock is lost before Unlock.
+	TryLock(ctx context.Context) (lost <-chan struct{}, ok bool, err error)
+	Unlock(ctx context.Context) error
+	// Holder names the instance holding the lock, empty when it is free.
+	Holder(ctx context.Context) (string, error)
+}
+
+// Leadership reports how the worker fared in the leader election.
+type Leadership struct {
+	// Leader is whether this instance ran the last post-processing itself.
+	Leader bool `json:"leader"`
+	// Holder is the instance that held the lock when this one last lost out.
+	Holder       string    `json:"holder"`
+	Acquired     int64     `json:"acquired"`
+	Skipped      int64     `json:"skipped"`
+	Lost         int64     `json:"lost"`
+	LastLeaderAt time.Time `json:"last_leader_at"`
+}
+
 type Worker struct {
 	transactionService *transaction.Service
-	interval           time.Duration
-	stopChan           chan struct{}
-	processingDone     chan struct{}
+	locker             Locker
+	runner
+
+	mu         sync.Mutex
+	leadership Leadership
+	// lost is closed when the lock this instance holds across runs is lost;
+	// nil while another instance leads.
+	lost <-chan struct{}
 }
 
-func NewWorker(ts *transaction.Service, interval time.Duration) *Worker {
+// NewWorker returns a post-processing worker. With a locker, only the
+// instance holding the lock runs, and it keeps the lock from one run to the
+// next until a run fails or the worker stops; a nil locker runs every tick.
+func NewWorker(ts *transaction.Service, interval time.Duration, locker Locker) *Worker {
 	return &Worker{
 		transactionService: ts,
-		interval:           interval,
-		stopChan:           make(chan struct{}),
-		processingDone:     make(chan struct{}),
+		locker:             locker,
+		runner:             newRunner(postProcessingWorker, interval),
 	}
 }
 
+// Leadership returns a snapshot of the worker's leader election state.
+func (w *Worker) Leadership() Leadership {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.leadership
+}
+
+// Start runs post-processing every interval until ctx is done or Stop is
+// called.
 func (w *Worker) Start(ctx context.Context) {
-	ticker := time.NewTicker(w.interval)
-	defer ticker.Stop()
+	w.start(ctx, w.runPostProcessing)
+	w.resign(context.WithoutCancel(ctx))
+}
+
+func (w *Worker) runPostProcessing(ctx context.Context) error {
+	if w.locker == nil {
+		return w.postProcess(ctx)
+	}
 
-	for {
+	lost, err := w.elect(ctx)
+	if err != nil {
+		logger.Error("Failed to take post-processing lock", err)
+		return err
+	}
+	if lost == nil {
+		w.follow(ctx)
+		return nil
+	}
+	w.lead()
+
+	// Abort the run as soon as the lock is gone, another instance may take over
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
 		select {
-		case <-ctx.Done():
-			close(w.processingDone)
-			return
-		case <-w.stopChan:
-			close(w.processingDone)
-			return
-		case <-ticker.C:
-			w.runPostProcessing(ctx)
+		case <-lost:
+			logger.Warn("Lost post-processing lock, aborting run")
+			cancel()
+		case <-done:
 		}
+	}()
+
+	err = w.postProcess(runCtx)
+	close(done)
+	cancel()
+
+	// Hand the leadership over, so a healthy instance can take the next run
+	if err != nil {
+		w.resign(context.WithoutCancel(ctx))
 	}
+	return err
 }
 
-func (w *Worker) runPostProcessing(ctx context.Context) {
-	err := w.transactionService.PostProcess(ctx)
-	if err != nil {
-		logger.Error("Failed to run post-processing", err)
+// elect returns the channel of the lock held by this instance, taking the
+// lock first if it does not hold it yet. It returns nil while another
+// instance holds the lock.
+func (w *Worker) elect(ctx context.Context) (<-chan struct{}, error) {
+	w.mu.Lock()
+	held := w.lost
+	w.mu.Unlock()
+
+	if held != nil {
+		select {
+		case <-held:
+			w.mu.Lock()
+			w.leadership.Lost++
+			w.leadership.Leader = false
+			w.mu.Unlock()
+			metrics.LockEvent(postProcessingWorker, "lost")
+			metrics.SetLeader(postProcessingWorker, false)
+			logger.Warn("Lost post-processing leadership")
+			// Clears the dead session; the lock went with it
+			w.resign(ctx)
+		default:
+			return held, nil
+		}
+	}
+
+	lost, ok, err := w.locker.TryLock(ctx)
+	if err != nil || !ok {
+		return nil, err
+	}
+	w.mu.Lock()
+	w.lost = lost
+	w.mu.Unlock()
+	return lost, nil
+}
+
+// resign releases the lock if this instance holds it.
+func (w *Worker) resign(ctx context.Context) {
+	w.mu.Lock()
+	held := w.lost != nil
+	w.lost = nil
+	w.leadership.Leader = false
+	w.mu.Unlock()
+
+	if !held {
 		return
 	}
+	metrics.SetLeader(postProcessingWorker, false)
+	// Released outside the mutex, so Leadership does not wait on the database
+	if err := w.locker.Unlock(ctx); err != nil {
+		logger.Error("Failed to release post-processing lock", err)
+	}
+}
+
+// lead records a run in which this instance holds the lock. Only runs that
+// take over the leadership count as acquisitions.
+func (w *Worker) lead() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.leadership.Leader {
+		logger.Info("Acquired post-processing leadership")
+		w.leadership.Acquired++
+		metrics.LockEvent(postProcessingWorker, "acquired")
+		metrics.SetLeader(postProcessingWorker, true)
+	}
+	w.leadership.Leader = true
+	w.leadership.Holder = ""
+	w.leadership.LastLeaderAt = time.Now()
+}
+
+// follow records a run skipped because another instance holds the lock.
+func (w *Worker) follow(ctx context.Context) {
+	holder, err := w.locker.Holder(ctx)
+	if err != nil {
+		logger.Error("Failed to look up post-processing leader", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.leadership.Leader || w.leadership.Holder != holder {
+		logger.Info("Another instance runs post-processing", "holder", holder)
+	}
+	w.leadership.Leader = false
+	w.leadership.Holder = holder
+	w.leadership.Skipped++
+
+	metrics.LockEvent(postProcessingWorker, "skipped")
+	metrics.SetLeader(postProcessingWorker, false)
+}
+
+func (w *Worker) postProcess(ctx context.Context) error {
+	results, err := w.transactionService.PostProcess(ctx)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to run post-processing", err)
+		return err
+	}
+
+	for _, result := range results {
+		if result.DryRun {
+			if len(result.Selected) > 0 {
+				logger.Info("Post-processing dry run would cancel transactions",
+					"account_id", result.AccountID,
+					"policy", result.Policy,
+					"selected", result.Selected)
+			}
+			continue
+		}
+		cancellation := result.Cancellation
+		if cancellation != nil {
+			metrics.TransactionsCanceled(len(cancellation.Canceled))
+		}
+		if cancellation != nil && (len(cancellation.Skipped) > 0 || !cancellation.Debt.IsZero()) {
+			logger.Warn("Cancellations would overdraw account",
+				"account_id", cancellation.AccountID,
+				"policy", string(cancellation.Policy),
+				"skipped", cancellation.Skipped,
+				"debt", cancellation.Debt.String())
+		}
+	}
 
-	logger.Info("Post-processing completed")
+	logger.InfoContext(ctx, "Post-processing completed", "accounts", len(results))
+	return nil
 }
 
-func (w *Worker) Stop() {
-	close(w.stopChan)
-	<-w.processingDone
+// Stop waits for an in-flight run to finish, aborting it once ctx is done,
+// and gives up the leadership.
+func (w *Worker) Stop(ctx context.Context) error {
+	err := w.stop(ctx)
+	w.resign(context.WithoutCancel(ctx))
+	return err
 }
@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/transaction"
+)
+
+// sharedLock is a lock held by at most one of its lockers at a time, like
+// an advisory lock shared by replicas.
+type sharedLock struct {
+	mu     sync.Mutex
+	holder string
+}
+
+type sharedLocker struct {
+	lock *sharedLock
+	name string
+}
+
+func (l *sharedLocker) TryLock(context.Context) (<-chan struct{}, bool, error) {
+	l.lock.mu.Lock()
+	defer l.lock.mu.Unlock()
+
+	if l.lock.holder != "" {
+		return nil, false, nil
+	}
+	l.lock.holder = l.name
+	return make(chan struct{}), true, nil
+}
+
+func (l *sharedLocker) Unlock(context.Context) error {
+	l.lock.mu.Lock()
+	defer l.lock.mu.Unlock()
+
+	if l.lock.holder == l.name {
+		l.lock.holder = ""
+	}
+	return nil
+}
+
+func (l *sharedLocker) Holder(context.Context) (string, error) {
+	l.lock.mu.Lock()
+	defer l.lock.mu.Unlock()
+	return l.lock.holder, nil
+}
+
+// batchRepository counts the batches post-processing cancels.
+type batchRepository struct {
+	transaction.Repository
+
+	mu      sync.Mutex
+	batches int
+	err     error
+}
+
+func (r *batchRepository) ListAccountIDs(context.Context) ([]int64, error) {
+	return []int64{1}, nil
+}
+
+func (r *batchRepository) List(context.Context, transaction.Filter) ([]*transaction.Transaction, error) {
+	return []*transaction.Transaction{{ID: 1}, {ID: 2}}, nil
+}
+
+func (r *batchRepository) MarkAsCanceled(_ context.Context, accountID int64, _ []int64, policy transaction.OverdraftPolicy) (*transaction.CancellationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.batches++
+	return &transaction.CancellationResult{AccountID: accountID, Policy: policy}, nil
+}
+
+func (r *batchRepository) Batches() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestWorkersCancelOneBatchPerInterval(t *testing.T) {
+	ctx := context.Background()
+	repo := &batchRepository{}
+	service := transaction.NewService(repo, transaction.PostProcessConfig{})
+	lock := &sharedLock{}
+	first := NewWorker(service, time.Minute, &sharedLocker{lock: lock, name: "first"})
+	second := NewWorker(service, time.Minute, &sharedLocker{lock: lock, name: "second"})
+
+	// Both replicas tick over the same intervals, in either order
+	for interval := 1; interval <= 3; interval++ {
+		workers := []*Worker{first, second}
+		if interval%2 == 0 {
+			workers = []*Worker{second, first}
+		}
+		for _, w := range workers {
+			assert.NoError(t, w.runPostProcessing(ctx))
+		}
+		assert.Equal(t, interval, repo.Batches(), "exactly one batch is canceled per interval")
+	}
+
+	assert.True(t, first.Leadership().Leader, "the leader keeps the lock between runs")
+	assert.Equal(t, int64(1), first.Leadership().Acquired, "only taking over the leadership counts")
+	assert.False(t, second.Leadership().Leader)
+	assert.Equal(t, "first", second.Leadership().Holder)
+	assert.Equal(t, int64(3), second.Leadership().Skipped)
+
+	// A failed run hands the leadership over
+	repo.err = errors.New("database unavailable")
+	assert.Error(t, first.runPostProcessing(ctx))
+	assert.False(t, first.Leadership().Leader)
+	repo.err = nil
+
+	assert.NoError(t, second.runPostProcessing(ctx))
+	assert.NoError(t, first.runPostProcessing(ctx))
+	assert.Equal(t, 4, repo.Batches())
+	assert.True(t, second.Leadership().Leader)
+
+	// So does shutting down
+	go second.Start(ctx)
+	assert.NoError(t, second.Stop(ctx))
+	assert.False(t, second.Leadership().Leader)
+
+	assert.NoError(t, first.runPostProcessing(ctx))
+	assert.Equal(t, 5, repo.Batches())
+	assert.True(t, first.Leadership().Leader)
+	assert.Equal(t, int64(2), first.Leadership().Acquired)
+}
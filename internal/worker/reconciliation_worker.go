@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/reconciliation"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// ReconciliationWorker periodically checks account balances against their
+// transactions and ledger, optionally repairing the ones that disagree.
+type ReconciliationWorker struct {
+	reconciliationService *reconciliation.Service
+	repair                bool
+	runner
+}
+
+func NewReconciliationWorker(rs *reconciliation.Service, interval time.Duration, repair bool) *ReconciliationWorker {
+	return &ReconciliationWorker{
+		reconciliationService: rs,
+		repair:                repair,
+		runner:                newRunner("reconciliation", interval),
+	}
+}
+
+func (w *ReconciliationWorker) Start(ctx context.Context) {
+	w.start(ctx, w.runReconciliation)
+}
+
+func (w *ReconciliationWorker) runReconciliation(ctx context.Context) error {
+	report, err := w.reconciliationService.Run(ctx, reconciliation.Options{
+		Repair: w.repair,
+		Note:   "scheduled reconciliation",
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to run reconciliation", err)
+		return err
+	}
+
+	for _, d := range report.Discrepancies {
+		logger.Warn("Balance discrepancy found",
+			"account_id", d.AccountID,
+			"stored", d.Stored.String(),
+			"expected", d.Expected.String(),
+			"ledger", d.Ledger.String(),
+			"offending_rows", len(d.OffendingRows))
+	}
+
+	logger.InfoContext(ctx, "Reconciliation completed",
+		"checked", report.Checked,
+		"discrepancies", len(report.Discrepancies),
+		"repaired", len(report.Repairs))
+	return nil
+}
+
+// Stop waits for an in-flight run to finish, aborting it once ctx is done.
+func (w *ReconciliationWorker) Stop(ctx context.Context) error {
+	return w.stop(ctx)
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/domain/round"
+	"github.com/blackcloro/transaction-processor/pkg/logger"
+)
+
+// RoundWorker periodically closes the rounds that have been open for longer
+// than the round timeout, such as lost rounds that never see a win.
+type RoundWorker struct {
+	roundService *round.Service
+	timeout      time.Duration
+	runner
+}
+
+func NewRoundWorker(rs *round.Service, interval, timeout time.Duration) *RoundWorker {
+	return &RoundWorker{
+		roundService: rs,
+		timeout:      timeout,
+		runner:       newRunner("rounds", interval),
+	}
+}
+
+func (w *RoundWorker) Start(ctx context.Context) {
+	w.start(ctx, w.closeStaleRounds)
+}
+
+func (w *RoundWorker) closeStaleRounds(ctx context.Context) error {
+	closed, err := w.roundService.CloseStale(ctx, w.timeout)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to close stale rounds", err)
+		return err
+	}
+
+	if closed > 0 {
+		logger.InfoContext(ctx, "Stale rounds closed", "closed", closed, "timeout", w.timeout.String())
+	}
+	return nil
+}
+
+// Stop waits for an in-flight run to finish, aborting it once ctx is done.
+func (w *RoundWorker) Stop(ctx context.Context) error {
+	return w.stop(ctx)
+}
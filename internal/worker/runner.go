@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blackcloro/transaction-processor/internal/metrics"
+)
+
+// runner calls a job every interval, one run at a time, until it is stopped.
+type runner struct {
+	name           string
+	interval       time.Duration
+	stopChan       chan struct{}
+	processingDone chan struct{}
+
+	mu          sync.Mutex
+	cancelRun   context.CancelFunc
+	lastSuccess time.Time
+}
+
+func newRunner(name string, interval time.Duration) runner {
+	return runner{
+		name:           name,
+		interval:       interval,
+		stopChan:       make(chan struct{}),
+		processingDone: make(chan struct{}),
+	}
+}
+
+func (r *runner) start(ctx context.Context, run func(ctx context.Context) error) {
+	defer close(r.processingDone)
+
+	// Runs get their own context so Stop can abort one that overruns
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r.mu.Lock()
+	r.cancelRun = cancel
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopChan:
+			return
+		case <-ticker.C:
+			started := time.Now()
+			err := run(runCtx)
+			metrics.ObserveWorkerRun(r.name, time.Since(started), err)
+			if err == nil {
+				r.mu.Lock()
+				r.lastSuccess = time.Now()
+				r.mu.Unlock()
+			}
+		}
+	}
+}
+
+// LastSuccess returns when a run last completed without error, zero if none
+// has yet.
+func (r *runner) LastSuccess() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastSuccess
+}
+
+// stop waits for an in-flight run to finish. Once ctx is done, the run is
+// aborted instead and stop reports that it did not finish in time.
+func (r *runner) stop(ctx context.Context) error {
+	close(r.stopChan)
+
+	select {
+	case <-r.processingDone:
+		return nil
+	case <-ctx.Done():
+	}
+
+	r.mu.Lock()
+	cancel := r.cancelRun
+	r.mu.Unlock()
+	if cancel == nil {
+		return fmt.Errorf("worker never started: %w", ctx.Err())
+	}
+	cancel()
+	<-r.processingDone
+	return fmt.Errorf("run did not finish in time: %w", ctx.Err())
+}
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_account_processed_at;
+ALTER TABLE transactions ALTER COLUMN account_id DROP NOT NULL;
+
+ALTER TABLE account DROP COLUMN IF EXISTS created_at;
+ALTER TABLE account ALTER COLUMN id SET DEFAULT 1;
+DROP SEQUENCE IF EXISTS account_id_seq;
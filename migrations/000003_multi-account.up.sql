@@ -0,0 +1,10 @@
+-- Accounts are no longer a single hard-coded row: allocate ids from a sequence
+CREATE SEQUENCE IF NOT EXISTS account_id_seq OWNED BY account.id;
+SELECT setval('account_id_seq', COALESCE((SELECT MAX(id) FROM account), 0) + 1, false);
+
+ALTER TABLE account ALTER COLUMN id SET DEFAULT nextval('account_id_seq');
+ALTER TABLE account ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Every transaction belongs to exactly one account
+ALTER TABLE transactions ALTER COLUMN account_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_account_processed_at ON transactions (account_id, processed_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_account_keyset;
+CREATE INDEX IF NOT EXISTS idx_transactions_account_processed_at ON transactions (account_id, processed_at DESC);
@@ -0,0 +1,3 @@
+-- Keyset pagination walks (processed_at, id) per account, newest first
+DROP INDEX IF EXISTS idx_transactions_account_processed_at;
+CREATE INDEX IF NOT EXISTS idx_transactions_account_keyset ON transactions (account_id, processed_at DESC, id DESC);
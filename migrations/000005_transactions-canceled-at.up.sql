@@ -0,0 +1,5 @@
+-- Record when a transaction was canceled so statements can place the reversal in time
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS canceled_at TIMESTAMP WITH TIME ZONE;
+
+-- The real cancellation time of older rows is unknown; processed_at is the closest approximation
+UPDATE transactions SET canceled_at = processed_at WHERE is_canceled = true AND canceled_at IS NULL;
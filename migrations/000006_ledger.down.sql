@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS postings;
+DROP FUNCTION IF EXISTS postings_append_only();
+DROP SEQUENCE IF EXISTS ledger_entry_id_seq;
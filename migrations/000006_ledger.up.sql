@@ -0,0 +1,81 @@
+-- Append-only double-entry ledger. Every balance change is an entry made of
+-- two postings of equal amount: one on the player's book and its mirror on the
+-- house book. The player balance is credits minus debits on the player book.
+CREATE SEQUENCE IF NOT EXISTS ledger_entry_id_seq;
+
+CREATE TABLE IF NOT EXISTS postings
+(
+    id              BIGSERIAL PRIMARY KEY,
+    entry_id        BIGINT                   NOT NULL,
+    account_id      INTEGER                  NOT NULL REFERENCES account (id),
+    book            VARCHAR(10)              NOT NULL CHECK (book IN ('player', 'house')),
+    direction       VARCHAR(6)               NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount          DECIMAL(15, 5)           NOT NULL CHECK (amount >= 0),
+    transaction_ref INTEGER REFERENCES transactions (id),
+    reason          VARCHAR(20)              NOT NULL CHECK (reason IN ('apply', 'cancel', 'opening')),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_postings_account_book ON postings (account_id, book, created_at);
+CREATE INDEX IF NOT EXISTS idx_postings_transaction_ref ON postings (transaction_ref);
+
+CREATE OR REPLACE FUNCTION postings_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'postings are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER postings_append_only
+    BEFORE UPDATE OR DELETE
+    ON postings
+    FOR EACH ROW
+EXECUTE FUNCTION postings_append_only();
+
+-- Backfill: one entry per applied transaction...
+WITH entries AS (SELECT nextval('ledger_entry_id_seq') AS entry_id, id, account_id, state, amount, processed_at
+                 FROM transactions
+                 ORDER BY id)
+INSERT
+INTO postings (entry_id, account_id, book, direction, amount, transaction_ref, reason, created_at)
+SELECT entry_id, account_id, 'player', CASE WHEN state = 'win' THEN 'credit' ELSE 'debit' END, amount, id, 'apply',
+       COALESCE(processed_at, CURRENT_TIMESTAMP)
+FROM entries
+UNION ALL
+SELECT entry_id, account_id, 'house', CASE WHEN state = 'win' THEN 'debit' ELSE 'credit' END, amount, id, 'apply',
+       COALESCE(processed_at, CURRENT_TIMESTAMP)
+FROM entries;
+
+-- ...one reversing entry per canceled transaction...
+WITH entries AS (SELECT nextval('ledger_entry_id_seq') AS entry_id, id, account_id, state, amount, canceled_at
+                 FROM transactions
+                 WHERE is_canceled = true
+                 ORDER BY id)
+INSERT
+INTO postings (entry_id, account_id, book, direction, amount, transaction_ref, reason, created_at)
+SELECT entry_id, account_id, 'player', CASE WHEN state = 'win' THEN 'debit' ELSE 'credit' END, amount, id, 'cancel',
+       COALESCE(canceled_at, CURRENT_TIMESTAMP)
+FROM entries
+UNION ALL
+SELECT entry_id, account_id, 'house', CASE WHEN state = 'win' THEN 'credit' ELSE 'debit' END, amount, id, 'cancel',
+       COALESCE(canceled_at, CURRENT_TIMESTAMP)
+FROM entries;
+
+-- ...and an opening entry for whatever part of the stored balance history does not explain
+WITH derived AS (SELECT a.id                                                                  AS account_id,
+                        a.balance - COALESCE(SUM(CASE
+                                                     WHEN p.direction = 'credit' THEN p.amount
+                                                     ELSE -p.amount END), 0)                  AS diff
+                 FROM account a
+                          LEFT JOIN postings p ON p.account_id = a.id AND p.book = 'player'
+                 GROUP BY a.id, a.balance),
+     entries AS (SELECT nextval('ledger_entry_id_seq') AS entry_id, account_id, diff
+                 FROM derived
+                 WHERE diff <> 0)
+INSERT
+INTO postings (entry_id, account_id, book, direction, amount, transaction_ref, reason)
+SELECT entry_id, account_id, 'player', CASE WHEN diff > 0 THEN 'credit' ELSE 'debit' END, ABS(diff), NULL, 'opening'
+FROM entries
+UNION ALL
+SELECT entry_id, account_id, 'house', CASE WHEN diff > 0 THEN 'debit' ELSE 'credit' END, ABS(diff), NULL, 'opening'
+FROM entries;
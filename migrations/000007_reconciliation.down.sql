@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS balance_audits;
+
+ALTER TABLE postings DROP CONSTRAINT IF EXISTS postings_reason_check;
+ALTER TABLE postings ADD CONSTRAINT postings_reason_check CHECK (reason IN ('apply', 'cancel', 'opening'));
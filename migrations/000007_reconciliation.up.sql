@@ -0,0 +1,18 @@
+-- Reconciliation repairs are booked as adjustment postings
+ALTER TABLE postings DROP CONSTRAINT IF EXISTS postings_reason_check;
+ALTER TABLE postings ADD CONSTRAINT postings_reason_check CHECK (reason IN ('apply', 'cancel', 'opening', 'adjustment'));
+
+-- Every repair done by the reconciler leaves an audit record
+CREATE TABLE IF NOT EXISTS balance_audits
+(
+    id               BIGSERIAL PRIMARY KEY,
+    account_id       INTEGER                  NOT NULL REFERENCES account (id),
+    previous_balance DECIMAL(15, 5)           NOT NULL,
+    new_balance      DECIMAL(15, 5)           NOT NULL,
+    ledger_balance   DECIMAL(15, 5)           NOT NULL,
+    adjusted_rows    INTEGER                  NOT NULL DEFAULT 0,
+    note             TEXT                     NOT NULL DEFAULT '',
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_audits_account ON balance_audits (account_id, created_at);
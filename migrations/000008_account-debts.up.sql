@@ -0,0 +1,15 @@
+-- Part of a win reversal the balance could not cover when the win was
+-- canceled. Recoverable rows are debts of the account (clamp policy); the
+-- others were written off (partial policy).
+CREATE TABLE IF NOT EXISTS account_debts
+(
+    id              BIGSERIAL PRIMARY KEY,
+    account_id      INTEGER                  NOT NULL REFERENCES account (id),
+    transaction_ref INTEGER                  NOT NULL REFERENCES transactions (id),
+    amount          DECIMAL(15, 5)           NOT NULL CHECK (amount > 0),
+    recoverable     BOOLEAN                  NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_debts_account ON account_debts (account_id);
+CREATE INDEX IF NOT EXISTS idx_account_debts_transaction_ref ON account_debts (transaction_ref);
@@ -0,0 +1,14 @@
+-- Keys providers authenticate with. Only the SHA-256 of a key is stored; the
+-- prefix is kept in clear to tell keys apart. An empty account_ids allows
+-- every account.
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           BIGSERIAL PRIMARY KEY,
+    name         VARCHAR(100)             NOT NULL,
+    prefix       VARCHAR(16)              NOT NULL,
+    key_hash     BYTEA                    NOT NULL UNIQUE,
+    source_types TEXT[]                   NOT NULL CHECK (source_types <@ ARRAY ['game', 'server', 'payment']),
+    account_ids  BIGINT[]                 NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
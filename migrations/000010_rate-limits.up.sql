@@ -0,0 +1,11 @@
+-- Request counters of the Postgres rate limit store, one row per bucket
+-- holding the count of its current fixed window.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits
+(
+    bucket       TEXT PRIMARY KEY,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    count        INTEGER                  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_expires_at ON rate_limits (expires_at);
@@ -0,0 +1,4 @@
+-- The balance right after a transaction was applied, so a resubmitted
+-- transaction can be answered with its original result. Unknown for the
+-- transactions processed before this migration.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS balance_after DECIMAL(15, 5);
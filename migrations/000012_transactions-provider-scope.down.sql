@@ -0,0 +1,3 @@
+-- Fails if providers have reused each other's transaction IDs in the meantime
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_source_type_transaction_id_key;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_id_key UNIQUE (transaction_id);
@@ -0,0 +1,5 @@
+-- Transaction IDs are assigned by the providers, so they are only unique per
+-- provider. The new constraint's index also serves lookups by provider and ID.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_transaction_id_key;
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_source_type_transaction_id_key UNIQUE (source_type, transaction_id);
@@ -0,0 +1,6 @@
+-- Fails while rollbacks are stored
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_reverses_id_check;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reverses_id;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_state_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_state_check CHECK (state IN ('win', 'lost'));
@@ -0,0 +1,10 @@
+-- A rollback reverses one earlier transaction of the same account and
+-- provider. The reversed transaction is canceled, and the unique reference
+-- keeps it from being reversed by a second rollback.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_state_check;
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_state_check CHECK (state IN ('win', 'lost', 'rollback'));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reverses_id INTEGER UNIQUE REFERENCES transactions (id);
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_reverses_id_check CHECK ((state = 'rollback') = (reverses_id IS NOT NULL));
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_round;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_round_fkey;
+ALTER TABLE transactions DROP COLUMN IF EXISTS round_id;
+
+DROP TABLE IF EXISTS rounds;
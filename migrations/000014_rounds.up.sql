@@ -0,0 +1,23 @@
+-- Game rounds: the bets and the win of one round of an account. Round IDs are
+-- assigned by the providers, so like transaction IDs they are unique per
+-- provider.
+CREATE TABLE IF NOT EXISTS rounds
+(
+    id          BIGSERIAL PRIMARY KEY,
+    round_id    VARCHAR(255)             NOT NULL,
+    account_id  INTEGER                  NOT NULL REFERENCES account (id),
+    source_type VARCHAR(20)              NOT NULL,
+    status      VARCHAR(10)              NOT NULL CHECK (status IN ('open', 'closed')),
+    opened_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    closed_at   TIMESTAMP WITH TIME ZONE,
+    UNIQUE (source_type, round_id),
+    CHECK ((status = 'closed') = (closed_at IS NOT NULL))
+);
+
+-- Serves the sweep closing rounds that stayed open for too long
+CREATE INDEX IF NOT EXISTS idx_rounds_open ON rounds (opened_at) WHERE status = 'open';
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS round_id VARCHAR(255);
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_round_fkey FOREIGN KEY (source_type, round_id) REFERENCES rounds (source_type, round_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_round ON transactions (source_type, round_id) WHERE round_id IS NOT NULL;
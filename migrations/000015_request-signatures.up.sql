@@ -0,0 +1,9 @@
+-- Signatures of handled signed requests, kept until their timestamp is
+-- stale so a replay is rejected by every instance.
+CREATE UNLOGGED TABLE IF NOT EXISTS request_signatures
+(
+    signature  TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_signatures_expires_at ON request_signatures (expires_at);
@@ -3,10 +3,14 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Logger struct {
@@ -15,18 +19,61 @@ type Logger struct {
 
 var defaultLogger *Logger
 
-func InitLogger() {
-	opts := &slog.HandlerOptions{
-		Level:     slog.LevelInfo,
+// Options selects the output of the logger.
+type Options struct {
+	// Format is text or json.
+	Format string
+	// Level is debug, info, warn or error.
+	Level string
+	// Output defaults to stdout.
+	Output io.Writer
+}
+
+func init() {
+	// Log with the defaults until InitLogger is called
+	defaultLogger = newLogger(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+}
+
+// InitLogger replaces the default logger according to opts.
+func InitLogger(opts Options) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q", opts.Level)
+	}
+
+	output := opts.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:     level,
 		AddSource: false,
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, opts)
-	slogger := slog.New(handler)
-	defaultLogger = &Logger{slogger: slogger}
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "text", "":
+		handler = slog.NewTextHandler(output, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(output, handlerOpts)
+	default:
+		return fmt.Errorf("invalid log format %q", opts.Format)
+	}
+
+	defaultLogger = newLogger(handler)
+	return nil
+}
+
+func newLogger(handler slog.Handler) *Logger {
+	return &Logger{slogger: slog.New(contextHandler{handler})}
 }
 
-func (l *Logger) log(level slog.Level, msg string, args ...any) {
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if !l.slogger.Enabled(ctx, level) {
+		return
+	}
+
 	_, file, line, ok := runtime.Caller(2)
 	if ok {
 		file = filepath.Base(file)
@@ -35,28 +82,119 @@ func (l *Logger) log(level slog.Level, msg string, args ...any) {
 		args = append(args, slog.String("source", source))
 	}
 
-	l.slogger.Log(context.Background(), level, msg, args...)
+	l.slogger.Log(ctx, level, msg, args...)
+}
+
+func Debug(msg string, args ...any) {
+	defaultLogger.log(context.Background(), slog.LevelDebug, msg, args...)
 }
 
 func Info(msg string, args ...any) {
-	defaultLogger.log(slog.LevelInfo, msg, args...)
+	defaultLogger.log(context.Background(), slog.LevelInfo, msg, args...)
 }
 
 func Error(msg string, err error, args ...any) {
 	if err != nil {
 		args = append(args, slog.Any("error", err))
 	}
-	defaultLogger.log(slog.LevelError, msg, args...)
+	defaultLogger.log(context.Background(), slog.LevelError, msg, args...)
 }
 
 func Warn(msg string, args ...any) {
-	defaultLogger.log(slog.LevelWarn, msg, args...)
+	defaultLogger.log(context.Background(), slog.LevelWarn, msg, args...)
 }
 
 func Fatal(msg string, err error, args ...any) {
 	if err != nil {
 		args = append(args, slog.Any("error", err))
 	}
-	defaultLogger.log(slog.LevelError, msg, args...)
+	defaultLogger.log(context.Background(), slog.LevelError, msg, args...)
 	os.Exit(1)
 }
+
+// DebugContext logs at debug level with the correlation IDs found in ctx.
+func DebugContext(ctx context.Context, msg string, args ...any) {
+	defaultLogger.log(ctx, slog.LevelDebug, msg, args...)
+}
+
+// InfoContext logs at info level with the correlation IDs found in ctx.
+func InfoContext(ctx context.Context, msg string, args ...any) {
+	defaultLogger.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+// WarnContext logs at warn level with the correlation IDs found in ctx.
+func WarnContext(ctx context.Context, msg string, args ...any) {
+	defaultLogger.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+// ErrorContext logs at error level with the correlation IDs found in ctx.
+func ErrorContext(ctx context.Context, msg string, err error, args ...any) {
+	if err != nil {
+		args = append(args, slog.Any("error", err))
+	}
+	defaultLogger.log(ctx, slog.LevelError, msg, args...)
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	transactionIDKey
+	accountIDKey
+)
+
+// WithRequestID returns a context whose log records carry the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// WithTransactionID returns a context whose log records carry the provider
+// transaction ID.
+func WithTransactionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, transactionIDKey, id)
+}
+
+// WithAccountID returns a context whose log records carry the account ID.
+func WithAccountID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, accountIDKey, id)
+}
+
+// contextHandler adds the correlation IDs found in the context of a record:
+// request, transaction and account IDs, and the trace and span IDs of the
+// active span.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id, ok := ctx.Value(transactionIDKey).(string); ok {
+		r.AddAttrs(slog.String("transaction_id", id))
+	}
+	if id, ok := ctx.Value(accountIDKey).(int64); ok {
+		r.AddAttrs(slog.Int64("account_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}